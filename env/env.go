@@ -1,6 +1,9 @@
 package env
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
 )
@@ -29,6 +32,41 @@ type E struct {
 	EmitterBufferSize    int `env:"EMITTER_BUFFER_SIZE" envDefault:"2048"`
 	HandlerSemaphoreSize int `env:"HANDLER_SEMAPHORE_SIZE" envDefault:"512"`
 
+	WebhookMaxAttempts    int           `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"10"`
+	WebhookBackoffInitial time.Duration `env:"WEBHOOK_BACKOFF_INITIAL" envDefault:"2s"`
+	WebhookBackoffMax     time.Duration `env:"WEBHOOK_BACKOFF_MAX" envDefault:"10m"`
+	WebhookFailedLimit    int64         `env:"WEBHOOK_FAILED_LIMIT" envDefault:"1000"` // dead-letter entries kept per instance
+
+	RabbitMQURL      string `env:"RABBITMQ_URL" envDefault:""` // amqp://<user>:<pass>@<host>:<port>/<vhost>, empty disables
+	RabbitMQExchange string `env:"RABBITMQ_EXCHANGE" envDefault:"whatsmiau"`
+
+	BrokerMaxInFlight int `env:"BROKER_MAX_IN_FLIGHT" envDefault:"256"` // published events waiting for the broker confirmation
+
+	SQSEnabled     bool   `env:"SQS_ENABLED" envDefault:"false"`
+	SQSRegion      string `env:"SQS_REGION" envDefault:"us-east-1"`
+	SQSEndpoint    string `env:"SQS_ENDPOINT" envDefault:""` // ex: http://localhost:4566 (LocalStack) or http://localhost:9324 (ElasticMQ)
+	SQSQueuePrefix string `env:"SQS_QUEUE_PREFIX" envDefault:"whatsmiau-"`
+	SQSQueueMode   string `env:"SQS_QUEUE_MODE" envDefault:"INSTANCE"` // INSTANCE or EVENT
+
+	MediaMaxSize         int64         `env:"MEDIA_MAX_SIZE" envDefault:"67108864"`        // bytes, limit of sent and received media
+	MediaConcurrency     int           `env:"MEDIA_CONCURRENCY" envDefault:"16"`           // media downloaded, converted or uploaded at the same time
+	MediaBase64MaxSize   int64         `env:"MEDIA_BASE64_MAX_SIZE" envDefault:"10485760"` // bytes, bigger received media only has the storage url in the webhook
+	MediaDownloadTimeout time.Duration `env:"MEDIA_DOWNLOAD_TIMEOUT" envDefault:"5m"`
+	MediaUploadTimeout   time.Duration `env:"MEDIA_UPLOAD_TIMEOUT" envDefault:"5m"`
+
+	StreamBufferSize int      `env:"STREAM_BUFFER_SIZE" envDefault:"512"`  // events kept per instance to resume SSE/WebSocket streams
+	StreamOrigins    []string `env:"STREAM_ORIGINS" envDefault:""`         // origins allowed to open the WebSocket stream, ex: app.example.com,*.example.com
+	MessageCacheSize int      `env:"MESSAGE_CACHE_SIZE" envDefault:"1000"` // messages kept per instance to be forwarded, 0 disables
+
+	MessageStore    string        `env:"MESSAGE_STORE" envDefault:""`         // sql (same database of DB_URL) or redis, empty disables
+	MessageStoreTTL time.Duration `env:"MESSAGE_STORE_TTL" envDefault:"720h"` // redis only
+	PollOptionsTTL  time.Duration `env:"POLL_OPTIONS_TTL" envDefault:"2160h"` // option names of the polls, votes of expired polls only carry the hashes
+
+	ReconnectBackoffInitial time.Duration `env:"RECONNECT_BACKOFF_INITIAL" envDefault:"5s"`
+	ReconnectBackoffMax     time.Duration `env:"RECONNECT_BACKOFF_MAX" envDefault:"5m"`
+	SupervisorInterval      time.Duration `env:"SUPERVISOR_INTERVAL" envDefault:"30s"` // how often every connection is checked
+	ZombieTimeout           time.Duration `env:"ZOMBIE_TIMEOUT" envDefault:"30m"`      // silence before an open connection is probed, 0 disables
+
 	ProxyAddresses []string `env:"PROXY_ADDRESSES" envDefault:""`      // random choices proxies ex: <SOCKS5|HTTP|HTTPS>://<username>:<password>@<host>:<port>
 	ProxyStrategy  string   `env:"PROXY_STRATEGY" envDefault:"RANDOM"` // todo: implement BALANCED
 	ProxyNoMedia   bool     `env:"PROXY_NO_MEDIA" envDefault:"false"`
@@ -43,7 +81,13 @@ var Env E
 
 func Load() error {
 	_ = godotenv.Load(".env")
-	err := env.Parse(&Env)
+	if err := env.Parse(&Env); err != nil {
+		return err
+	}
+
+	if Env.MediaConcurrency < 1 {
+		return fmt.Errorf("MEDIA_CONCURRENCY must be at least 1, got %d", Env.MediaConcurrency)
+	}
 
-	return err
+	return nil
 }
@@ -0,0 +1,12 @@
+package interfaces
+
+import "golang.org/x/net/context"
+
+// Confirm blocks until the broker accepted the published event
+type Confirm func(ctx context.Context) error
+
+// EventPublisher mirrors instance events to a message broker
+type EventPublisher interface {
+	// Publish sends the event without waiting for the broker, a nil Confirm means it was already accepted
+	Publish(ctx context.Context, instanceID, event string, payload []byte) (Confirm, error)
+}
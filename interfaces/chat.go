@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+// ChatRepository keeps the conversations of the instances
+type ChatRepository interface {
+	// Update applies the update to the chat, creating it when missing
+	Update(ctx context.Context, instanceID, remoteJid string, update *models.ChatUpdate) error
+	// Find returns a page of the matching chats, pinned first and then by the last activity, and the total
+	Find(ctx context.Context, filter *models.ChatFilter) ([]models.Chat, int, error)
+}
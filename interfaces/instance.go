@@ -8,6 +8,6 @@ import (
 type InstanceRepository interface {
 	Create(ctx context.Context, instance *models.Instance) error
 	List(ctx context.Context, id string) ([]models.Instance, error)
-	Update(ctx context.Context, id string, instance *models.Instance) (*models.Instance, error)
+	Update(ctx context.Context, id string, update *models.InstanceUpdate) (*models.Instance, error)
 	Delete(ctx context.Context, id string) error
 }
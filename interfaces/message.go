@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+// MessageRepository keeps the sent and received messages of the instances
+type MessageRepository interface {
+	// Save inserts the message or replaces it when already stored
+	Save(ctx context.Context, message *models.Message) error
+	// UpdateStatus replaces the status of the message unless it already has a later one (models.MessageStatusRank)
+	UpdateStatus(ctx context.Context, instanceID, id, status string) error
+	// Find returns a page of the matching messages, newest first, and the total of matches
+	Find(ctx context.Context, filter *models.MessageFilter) ([]models.Message, int, error)
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import "golang.org/x/net/context"
+
+// PollRepository keeps the option names of the polls, the votes only carry their hashes
+type PollRepository interface {
+	Store(ctx context.Context, instanceID, pollID string, options []string) error
+	// Options returns nil when the poll is unknown or expired
+	Options(ctx context.Context, instanceID, pollID string) ([]string, error)
+}
@@ -0,0 +1,25 @@
+package interfaces
+
+import (
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+// WebhookQueue keeps one ordered delivery queue per instance plus a dead-letter list
+type WebhookQueue interface {
+	Enqueue(ctx context.Context, delivery *models.WebhookDelivery) error
+	// Peek returns the head of the instance queue without removing it, nil when empty. Entries that can't be
+	// decoded are moved to the dead-letter list so they don't block the queue
+	Peek(ctx context.Context, instanceID string) (*models.WebhookDelivery, error)
+	// Retry replaces the head of the instance queue with the updated delivery
+	Retry(ctx context.Context, delivery *models.WebhookDelivery) error
+	// Ack removes the head of the instance queue
+	Ack(ctx context.Context, instanceID string) error
+	// Fail moves the head of the instance queue to the dead-letter list
+	Fail(ctx context.Context, delivery *models.WebhookDelivery) error
+	ListFailed(ctx context.Context, instanceID string, offset, limit int64) ([]models.WebhookDelivery, error)
+	// Pending lists the instances with queued deliveries
+	Pending(ctx context.Context) ([]string, error)
+	// Delete drops the queue and the dead-letter list of the instance
+	Delete(ctx context.Context, instanceID string) error
+}
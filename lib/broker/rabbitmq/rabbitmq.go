@@ -0,0 +1,124 @@
+package rabbitmq
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+var _ interfaces.EventPublisher = (*RabbitMQ)(nil)
+
+var ErrNotConfirmed = errors.New("rabbitmq: publish not confirmed by broker")
+
+type RabbitMQ struct {
+	url      string
+	exchange string
+
+	mu      sync.Mutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+}
+
+// New creates the publisher, the connection is opened on the first publish and
+// re-opened whenever the broker closes it
+func New(url, exchange string) *RabbitMQ {
+	return &RabbitMQ{
+		url:      url,
+		exchange: exchange,
+	}
+}
+
+// RoutingKey follows <instance>.<event>, ex: my-instance.messages.upsert
+func RoutingKey(instanceID, event string) string {
+	return fmt.Sprintf("%s.%s", instanceID, event)
+}
+
+// Publish returns as soon as the event is sent, the Confirm waits for the broker to persist it
+func (s *RabbitMQ) Publish(ctx context.Context, instanceID, event string, payload []byte) (interfaces.Confirm, error) {
+	channel, err := s.getChannel()
+	if err != nil {
+		return nil, err
+	}
+
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, s.exchange, RoutingKey(instanceID, event), false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		AppId:        "whatsmiau",
+		Type:         event,
+		Body:         payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) error {
+		acked, err := confirmation.WaitContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		if !acked {
+			return ErrNotConfirmed
+		}
+
+		return nil
+	}, nil
+}
+
+func (s *RabbitMQ) getChannel() (*amqp.Channel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.channel != nil && !s.channel.IsClosed() {
+		return s.channel, nil
+	}
+
+	if s.conn != nil && !s.conn.IsClosed() {
+		_ = s.conn.Close()
+	}
+
+	zap.L().Info("connecting to rabbitmq", zap.String("exchange", s.exchange))
+	conn, err := amqp.Dial(s.url)
+	if err != nil {
+		return nil, fmt.Errorf("rabbitmq: failed to connect: %w", err)
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("rabbitmq: failed to open channel: %w", err)
+	}
+
+	if err := channel.ExchangeDeclare(s.exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("rabbitmq: failed to declare exchange: %w", err)
+	}
+
+	if err := channel.Confirm(false); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("rabbitmq: failed to enable publisher confirms: %w", err)
+	}
+
+	s.conn = conn
+	s.channel = channel
+
+	return channel, nil
+}
+
+func (s *RabbitMQ) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil || s.conn.IsClosed() {
+		return nil
+	}
+
+	return s.conn.Close()
+}
@@ -0,0 +1,284 @@
+package sqs
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+var _ interfaces.EventPublisher = (*SQS)(nil)
+
+var (
+	ErrBufferFull      = errors.New("sqs: buffer is full")
+	ErrPayloadTooLarge = errors.New("sqs: payload is too large")
+)
+
+const (
+	// limits from SendMessageBatch
+	maxBatchEntries = 10
+	maxBatchBytes   = 256 * 1024
+
+	flushInterval = time.Second
+	sendTimeout   = 30 * time.Second
+	maxSending    = 8 // batches sent at the same time
+
+	attrInstance = "instance"
+	attrEvent    = "event"
+	attrType     = "String"
+)
+
+// QueueMode chooses how events are split between queues
+type QueueMode string
+
+const (
+	QueuePerInstance QueueMode = "INSTANCE" // <prefix><instance>
+	QueuePerEvent    QueueMode = "EVENT"    // <prefix><event>, ex: whatsmiau-messages-upsert
+)
+
+var invalidQueueChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+type Config struct {
+	Region      string
+	Endpoint    string // set it for LocalStack/ElasticMQ
+	QueuePrefix string
+	QueueMode   QueueMode
+	BufferSize  int
+}
+
+// api is the part of the SQS client used here
+type api interface {
+	GetQueueUrl(ctx context.Context, params *sqs.GetQueueUrlInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error)
+	SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error)
+}
+
+// SQS groups the events with SendMessageBatch, the credentials come from the AWS default chain
+// (environment, shared config, IRSA, ECS task role or instance profile)
+type SQS struct {
+	client      api
+	queuePrefix string
+	queueMode   QueueMode
+	queueUrls   *xsync.Map[string, string]
+	events      chan message
+	sending     chan struct{}
+}
+
+type message struct {
+	queue      string
+	instanceID string
+	event      string
+	body       string
+	done       chan error
+}
+
+// size is what SQS counts against the batch limit: the body and the name, type and value of each attribute
+func (m message) size() int {
+	return len(m.body) +
+		len(attrInstance) + len(attrType) + len(m.instanceID) +
+		len(attrEvent) + len(attrType) + len(m.event)
+}
+
+// New creates the sink and starts the goroutine that batches the events
+func New(ctx context.Context, cfg Config) (*SQS, error) {
+	awsConfig, err := config.LoadDefaultConfig(ctx, config.WithRegion(cfg.Region))
+	if err != nil {
+		return nil, fmt.Errorf("sqs: failed to load aws config: %w", err)
+	}
+
+	client := sqs.NewFromConfig(awsConfig, func(o *sqs.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	})
+
+	return newSQS(client, cfg), nil
+}
+
+func newSQS(client api, cfg Config) *SQS {
+	queueMode := QueueMode(strings.ToUpper(string(cfg.QueueMode)))
+	if queueMode != QueuePerEvent {
+		queueMode = QueuePerInstance
+	}
+
+	s := &SQS{
+		client:      client,
+		queuePrefix: cfg.QueuePrefix,
+		queueMode:   queueMode,
+		queueUrls:   xsync.NewMap[string, string](),
+		events:      make(chan message, cfg.BufferSize),
+		sending:     make(chan struct{}, maxSending),
+	}
+
+	go s.start()
+
+	return s
+}
+
+// QueueName returns the queue used for the event
+func (s *SQS) QueueName(instanceID, event string) string {
+	name := instanceID
+	if s.queueMode == QueuePerEvent {
+		name = strings.ReplaceAll(event, ".", "-")
+	}
+
+	name = invalidQueueChars.ReplaceAllString(s.queuePrefix+name, "_")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+
+	return name
+}
+
+// Publish buffers the event without blocking, it is confirmed when its batch is accepted by SQS
+func (s *SQS) Publish(_ context.Context, instanceID, event string, payload []byte) (interfaces.Confirm, error) {
+	done := make(chan error, 1)
+	msg := message{s.QueueName(instanceID, event), instanceID, event, string(payload), done}
+	if size := msg.size(); size > maxBatchBytes {
+		return nil, fmt.Errorf("%w: %d bytes with the attributes, the limit is %d", ErrPayloadTooLarge, size, maxBatchBytes)
+	}
+
+	select {
+	case s.events <- msg:
+	default:
+		return nil, ErrBufferFull
+	}
+
+	return func(ctx context.Context) error {
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}, nil
+}
+
+// start only groups the events, the batches are sent by their own goroutines
+func (s *SQS) start() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	pending := make(map[string][]message)
+	pendingBytes := make(map[string]int)
+	flush := func(queue string) {
+		go s.flush(queue, pending[queue])
+		delete(pending, queue)
+		delete(pendingBytes, queue)
+	}
+
+	for {
+		select {
+		case msg := <-s.events:
+			if pendingBytes[msg.queue]+msg.size() > maxBatchBytes {
+				flush(msg.queue)
+			}
+
+			pending[msg.queue] = append(pending[msg.queue], msg)
+			pendingBytes[msg.queue] += msg.size()
+			if len(pending[msg.queue]) >= maxBatchEntries {
+				flush(msg.queue)
+			}
+		case <-ticker.C:
+			for queue := range pending {
+				flush(queue)
+			}
+		}
+	}
+}
+
+// flush sends the batch once and resolves the confirmation of each message, failed ones are retried by the caller
+func (s *SQS) flush(queue string, messages []message) {
+	s.sending <- struct{}{}
+	defer func() { <-s.sending }()
+
+	failed, err := s.sendBatch(queue, messages)
+	for i, msg := range messages {
+		if err != nil {
+			msg.done <- err
+			continue
+		}
+		msg.done <- failed[i]
+	}
+}
+
+// sendBatch returns the error of each message that failed, the error is only set when the whole batch failed
+func (s *SQS) sendBatch(queue string, messages []message) (map[int]error, error) {
+	ctx, c := context.WithTimeout(context.Background(), sendTimeout)
+	defer c()
+
+	queueUrl, err := s.getQueueUrl(ctx, queue)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]types.SendMessageBatchRequestEntry, 0, len(messages))
+	for i, msg := range messages {
+		entries = append(entries, types.SendMessageBatchRequestEntry{
+			Id:          aws.String(strconv.Itoa(i)),
+			MessageBody: aws.String(msg.body),
+			MessageAttributes: map[string]types.MessageAttributeValue{
+				attrInstance: {DataType: aws.String(attrType), StringValue: aws.String(msg.instanceID)},
+				attrEvent:    {DataType: aws.String(attrType), StringValue: aws.String(msg.event)},
+			},
+		})
+	}
+
+	result, err := s.client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+		QueueUrl: aws.String(queueUrl),
+		Entries:  entries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sqs: failed to send batch to %s: %w", queue, err)
+	}
+
+	failed := make(map[int]error)
+	for _, item := range result.Failed {
+		i, err := strconv.Atoi(aws.ToString(item.Id))
+		if err != nil || i < 0 || i >= len(messages) {
+			continue
+		}
+
+		// sender faults (ex: invalid body) won't succeed on retry
+		if item.SenderFault {
+			zap.L().Error("sqs rejected message",
+				zap.String("queue", queue),
+				zap.String("instance", messages[i].instanceID),
+				zap.String("event", messages[i].event),
+				zap.String("code", aws.ToString(item.Code)),
+				zap.String("message", aws.ToString(item.Message)),
+			)
+			continue
+		}
+
+		failed[i] = fmt.Errorf("sqs: %s: %s", aws.ToString(item.Code), aws.ToString(item.Message))
+	}
+
+	return failed, nil
+}
+
+func (s *SQS) getQueueUrl(ctx context.Context, queue string) (string, error) {
+	if queueUrl, ok := s.queueUrls.Load(queue); ok {
+		return queueUrl, nil
+	}
+
+	result, err := s.client.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String(queue)})
+	if err != nil {
+		return "", fmt.Errorf("sqs: failed to get url of queue %s: %w", queue, err)
+	}
+
+	queueUrl := aws.ToString(result.QueueUrl)
+	s.queueUrls.Store(queue, queueUrl)
+
+	return queueUrl, nil
+}
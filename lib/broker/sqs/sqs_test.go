@@ -0,0 +1,198 @@
+package sqs
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"golang.org/x/net/context"
+)
+
+func TestQueueName(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     QueueMode
+		prefix   string
+		instance string
+		event    string
+		want     string
+	}{
+		{name: "per instance", mode: QueuePerInstance, prefix: "whatsmiau-", instance: "my-instance", event: "messages.upsert", want: "whatsmiau-my-instance"},
+		{name: "per event", mode: QueuePerEvent, prefix: "whatsmiau-", instance: "my-instance", event: "messages.upsert", want: "whatsmiau-messages-upsert"},
+		{name: "lowercase mode", mode: "event", prefix: "", instance: "my-instance", event: "connection.update", want: "connection-update"},
+		{name: "unknown mode is per instance", mode: "QUEUE", prefix: "", instance: "my-instance", event: "messages.upsert", want: "my-instance"},
+		{name: "invalid chars", mode: QueuePerInstance, prefix: "wm.", instance: "joão@5511 9", event: "messages.upsert", want: "wm_jo_o_5511_9"},
+		{name: "truncated to 80 chars", mode: QueuePerInstance, prefix: "whatsmiau-", instance: strings.Repeat("a", 100), event: "messages.upsert", want: "whatsmiau-" + strings.Repeat("a", 70)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSQS(nil, Config{QueuePrefix: tt.prefix, QueueMode: tt.mode})
+			if got := s.QueueName(tt.instance, tt.event); got != tt.want {
+				t.Errorf("QueueName(%q, %q) = %s, want %s", tt.instance, tt.event, got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeClient fails the entries with the bodies in fail, sender faults for the ones in reject
+type fakeClient struct {
+	mu      sync.Mutex
+	batches [][]string
+	fail    map[string]bool
+	reject  map[string]bool
+	err     error
+}
+
+func (f *fakeClient) GetQueueUrl(_ context.Context, params *sqs.GetQueueUrlInput, _ ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+	return &sqs.GetQueueUrlOutput{QueueUrl: aws.String("https://sqs.local/" + aws.ToString(params.QueueName))}, nil
+}
+
+func (f *fakeClient) SendMessageBatch(_ context.Context, params *sqs.SendMessageBatchInput, _ ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var bodies []string
+	var result sqs.SendMessageBatchOutput
+	for _, entry := range params.Entries {
+		body := aws.ToString(entry.MessageBody)
+		bodies = append(bodies, body)
+		switch {
+		case f.fail[body]:
+			result.Failed = append(result.Failed, types.BatchResultErrorEntry{Id: entry.Id, Code: aws.String("InternalError")})
+		case f.reject[body]:
+			result.Failed = append(result.Failed, types.BatchResultErrorEntry{Id: entry.Id, Code: aws.String("InvalidMessageContents"), SenderFault: true})
+		default:
+			result.Successful = append(result.Successful, types.SendMessageBatchResultEntry{Id: entry.Id})
+		}
+	}
+	f.batches = append(f.batches, bodies)
+
+	return &result, f.err
+}
+
+func TestPublishConfirms(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  *fakeClient
+		wantErr map[string]bool
+	}{
+		{
+			name:    "accepted",
+			client:  &fakeClient{},
+			wantErr: map[string]bool{},
+		},
+		{
+			name:    "failed entries are not confirmed",
+			client:  &fakeClient{fail: map[string]bool{"1": true}},
+			wantErr: map[string]bool{"1": true},
+		},
+		{
+			name:    "sender faults are dropped",
+			client:  &fakeClient{reject: map[string]bool{"1": true}},
+			wantErr: map[string]bool{},
+		},
+		{
+			name:    "failed batch",
+			client:  &fakeClient{err: errors.New("unavailable")},
+			wantErr: map[string]bool{"0": true, "1": true, "2": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSQS(tt.client, Config{QueuePrefix: "whatsmiau-", BufferSize: 16})
+
+			ctx, c := context.WithTimeout(context.Background(), 5*time.Second)
+			defer c()
+
+			bodies := []string{"0", "1", "2"}
+			for _, body := range bodies {
+				confirm, err := s.Publish(ctx, "instance", "messages.upsert", []byte(body))
+				if err != nil {
+					t.Fatalf("Publish() error: %v", err)
+				}
+
+				// confirmed on the next flush, together with the others
+				defer func() {
+					if err := confirm(ctx); (err != nil) != tt.wantErr[body] {
+						t.Errorf("confirm of %s error = %v, want error %v", body, err, tt.wantErr[body])
+					}
+				}()
+			}
+		})
+	}
+}
+
+func TestPublishBatchLimits(t *testing.T) {
+	client := &fakeClient{}
+	s := newSQS(client, Config{BufferSize: 32})
+
+	ctx, c := context.WithTimeout(context.Background(), 5*time.Second)
+	defer c()
+
+	// the body fits, its attributes don't
+	if _, err := s.Publish(ctx, "instance", "messages.upsert", make([]byte, maxBatchBytes)); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Errorf("Publish() of a payload over the limit error = %v, want %v", err, ErrPayloadTooLarge)
+	}
+
+	var confirms []func(context.Context) error
+	for range maxBatchEntries + 1 {
+		confirm, err := s.Publish(ctx, "instance", "messages.upsert", []byte("{}"))
+		if err != nil {
+			t.Fatalf("Publish() error: %v", err)
+		}
+		confirms = append(confirms, confirm)
+	}
+
+	for _, confirm := range confirms {
+		if err := confirm(ctx); err != nil {
+			t.Fatalf("confirm error: %v", err)
+		}
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if len(client.batches) != 2 || len(client.batches[0]) != maxBatchEntries || len(client.batches[1]) != 1 {
+		sizes := make([]int, 0, len(client.batches))
+		for _, batch := range client.batches {
+			sizes = append(sizes, len(batch))
+		}
+		t.Errorf("batch sizes = %v, want [%d 1]", sizes, maxBatchEntries)
+	}
+}
+
+func TestPublishBatchBytes(t *testing.T) {
+	client := &fakeClient{}
+	s := newSQS(client, Config{BufferSize: 32})
+
+	ctx, c := context.WithTimeout(context.Background(), 5*time.Second)
+	defer c()
+
+	// both bodies fit in one batch, not with the attributes
+	var confirms []func(context.Context) error
+	for _, body := range []string{strings.Repeat("a", maxBatchBytes/2), strings.Repeat("b", maxBatchBytes/2)} {
+		confirm, err := s.Publish(ctx, "instance", "messages.upsert", []byte(body))
+		if err != nil {
+			t.Fatalf("Publish() error: %v", err)
+		}
+		confirms = append(confirms, confirm)
+	}
+
+	for _, confirm := range confirms {
+		if err := confirm(ctx); err != nil {
+			t.Fatalf("confirm error: %v", err)
+		}
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if len(client.batches) != 2 {
+		t.Errorf("sent %d batches, want 2", len(client.batches))
+	}
+}
@@ -0,0 +1,87 @@
+package whatsmiau
+
+import (
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+// ReloadInstance applies the instance settings without reconnecting, used after updates
+func (s *Whatsmiau) ReloadInstance(old, updated *models.Instance) {
+	s.instanceCache.Delete(updated.ID)
+
+	if old.AlwaysOnline != updated.AlwaysOnline {
+		s.applyPresence(updated.ID, updated)
+	}
+}
+
+// DeleteInstance releases what the server keeps for an instance after it was logged out and deleted
+func (s *Whatsmiau) DeleteInstance(ctx context.Context, id string) error {
+	s.instanceCache.Delete(id)
+	return s.stopDeliveries(ctx, id)
+}
+
+// applyPresence keeps the instance available when AlwaysOnline is set, otherwise goes back to unavailable
+// so the phone keeps receiving notifications
+func (s *Whatsmiau) applyPresence(id string, instance *models.Instance) {
+	client, ok := s.clients.Load(id)
+	if !ok || !client.IsLoggedIn() {
+		return
+	}
+
+	presence := types.PresenceUnavailable
+	if instance.AlwaysOnline {
+		presence = types.PresenceAvailable
+	}
+
+	ctx, c := context.WithTimeout(context.Background(), 10*time.Second)
+	defer c()
+
+	if err := client.SendPresence(ctx, presence); err != nil {
+		zap.L().Error("failed to send presence", zap.String("id", id), zap.String("presence", string(presence)), zap.Error(err))
+	}
+}
+
+func (s *Whatsmiau) handleConnectedEvent(id string, instance *models.Instance) {
+	if instance.AlwaysOnline {
+		s.applyPresence(id, instance)
+	}
+}
+
+// autoRead marks incoming messages as read with ReadMessages and status broadcasts as viewed with ReadStatus
+func (s *Whatsmiau) autoRead(id string, instance *models.Instance, e *events.Message) {
+	if e.Info.IsFromMe {
+		return
+	}
+
+	// not shown as messages, reading them would mark the chat as read without the user seeing anything
+	if e.Message.GetProtocolMessage() != nil || e.Message.GetReactionMessage() != nil {
+		return
+	}
+
+	if e.Info.Chat == types.StatusBroadcastJID {
+		if !instance.ReadStatus {
+			return
+		}
+	} else if !instance.ReadMessages {
+		return
+	}
+
+	client, ok := s.clients.Load(id)
+	if !ok {
+		zap.L().Warn("failed to mark message as read", zap.String("id", id), zap.Error(whatsmeow.ErrClientIsNil))
+		return
+	}
+
+	ctx, c := context.WithTimeout(context.Background(), 10*time.Second)
+	defer c()
+
+	if err := client.MarkRead(ctx, []types.MessageID{e.Info.ID}, time.Now(), e.Info.Chat, e.Info.Sender); err != nil {
+		zap.L().Error("failed to mark message as read", zap.String("id", id), zap.String("message", e.Info.ID), zap.Error(err))
+	}
+}
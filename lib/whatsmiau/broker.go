@@ -0,0 +1,178 @@
+package whatsmiau
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/lib/broker/sqs"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+const (
+	brokerMaxAttempts = 5
+	brokerTimeout     = 10 * time.Second
+)
+
+type brokerEvent struct {
+	instanceID string
+	event      Wook
+	data       []byte
+	attempts   int
+}
+
+type pendingConfirm struct {
+	event   brokerEvent
+	confirm interfaces.Confirm
+}
+
+// brokerQueue publishes the events of one broker with its own goroutines, so a slow or offline broker
+// never blocks webhooks nor the other brokers
+type brokerQueue struct {
+	name      string
+	publisher interfaces.EventPublisher
+	events    chan brokerEvent
+	inflight  chan pendingConfirm
+}
+
+func newBrokerQueue(name string, publisher interfaces.EventPublisher) *brokerQueue {
+	queue := &brokerQueue{
+		name:      name,
+		publisher: publisher,
+		events:    make(chan brokerEvent, env.Env.EmitterBufferSize),
+		inflight:  make(chan pendingConfirm, env.Env.BrokerMaxInFlight),
+	}
+
+	go queue.start()
+	go queue.confirmations()
+
+	return queue
+}
+
+// enqueue never blocks, events are dropped when the broker can't keep up
+func (q *brokerQueue) enqueue(event brokerEvent) {
+	select {
+	case q.events <- event:
+	default:
+		zap.L().Error("broker buffer is full, dropping event", zap.String("broker", q.name), zap.String("instance", event.instanceID), zap.String("event", string(event.event)))
+	}
+}
+
+// start publishes without waiting for the confirmations, it only blocks when BROKER_MAX_IN_FLIGHT
+// events are waiting to be confirmed
+func (q *brokerQueue) start() {
+	for event := range q.events {
+		ctx, c := context.WithTimeout(context.Background(), brokerTimeout)
+		confirm, err := q.publisher.Publish(ctx, event.instanceID, string(event.event), event.data)
+		c()
+		if err != nil {
+			q.retry(event, err)
+			continue
+		}
+
+		if confirm != nil {
+			q.inflight <- pendingConfirm{event, confirm}
+		}
+	}
+}
+
+// confirmations waits the confirmations in the publish order, they usually arrive in that order too
+func (q *brokerQueue) confirmations() {
+	for pending := range q.inflight {
+		ctx, c := context.WithTimeout(context.Background(), brokerTimeout)
+		err := pending.confirm(ctx)
+		c()
+		if err != nil {
+			q.retry(pending.event, err)
+		}
+	}
+}
+
+// retry publishes the event again after a backoff, after the events published meanwhile
+func (q *brokerQueue) retry(event brokerEvent, err error) {
+	event.attempts++
+	// the same payload is rejected again
+	if errors.Is(err, sqs.ErrPayloadTooLarge) || event.attempts >= brokerMaxAttempts {
+		zap.L().Error("failed to publish event, dropping",
+			zap.String("broker", q.name),
+			zap.String("instance", event.instanceID),
+			zap.String("event", string(event.event)),
+			zap.Error(err),
+		)
+		return
+	}
+
+	zap.L().Warn("failed to publish event, retrying",
+		zap.String("broker", q.name),
+		zap.String("instance", event.instanceID),
+		zap.Int("attempt", event.attempts),
+		zap.Error(err),
+	)
+	time.AfterFunc(brokerBackoff(event.attempts), func() {
+		q.enqueue(event)
+	})
+}
+
+// brokerBackoff waits 1s, 2s, 4s... between attempts
+func brokerBackoff(attempts int) time.Duration {
+	return time.Second << (attempts - 1)
+}
+
+// brokerPayload returns the event without the base64 of the media, brokers limit the message size (256KB
+// in SQS) and the media is still in the storage url. data is the marshaled event, returned when unchanged
+func brokerPayload(event any, data []byte) ([]byte, error) {
+	wook, ok := event.(*WookEvent[WookMessageData])
+	if !ok || wook.Data == nil || wook.Data.Message == nil || wook.Data.Message.Base64 == "" {
+		return data, nil
+	}
+
+	message := *wook.Data.Message
+	message.Base64 = ""
+	messageData := *wook.Data
+	messageData.Message = &message
+	stripped := *wook
+	stripped.Data = &messageData
+
+	return json.Marshal(stripped)
+}
+
+// brokerAllows returns true if the broker is enabled for the event, an empty event list means every event
+func brokerAllows(broker *models.InstanceBroker, event Wook) bool {
+	if broker == nil || !broker.Enabled {
+		return false
+	}
+
+	return len(broker.Events) == 0 || slices.Contains(broker.Events, event.Name())
+}
+
+// enabledEvents merges the events wanted by the webhook and every enabled broker, live streams receive all events
+func (s *Whatsmiau) enabledEvents(instance *models.Instance) map[string]bool {
+	eventMap := make(map[string]bool)
+	if s.streaming(instance.ID) {
+		for _, event := range wookEvents {
+			eventMap[event.Name()] = true
+		}
+		return eventMap
+	}
+
+	if instance.Webhook != nil {
+		for _, event := range instance.Webhook.Events {
+			eventMap[event] = true
+		}
+	}
+
+	for _, broker := range []*models.InstanceBroker{instance.RabbitMQ, instance.SQS} {
+		for _, event := range wookEvents {
+			if brokerAllows(broker, event) {
+				eventMap[event.Name()] = true
+			}
+		}
+	}
+
+	return eventMap
+}
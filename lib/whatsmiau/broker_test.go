@@ -0,0 +1,207 @@
+package whatsmiau
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/lib/broker/sqs"
+	"golang.org/x/net/context"
+)
+
+// fakePublisher confirms an event when release is closed, failing the first confirmation of the events in fail
+type fakePublisher struct {
+	mu        sync.Mutex
+	published []string
+	fail      map[string]bool
+	release   chan struct{}
+	confirmed chan string
+}
+
+func (p *fakePublisher) Publish(_ context.Context, _, event string, _ []byte) (interfaces.Confirm, error) {
+	p.mu.Lock()
+	p.published = append(p.published, event)
+	p.mu.Unlock()
+
+	return func(ctx context.Context) error {
+		select {
+		case <-p.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.fail[event] {
+			delete(p.fail, event)
+			return errors.New("nack")
+		}
+
+		p.confirmed <- event
+		return nil
+	}, nil
+}
+
+func (p *fakePublisher) publishedCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.published)
+}
+
+func TestBrokerQueuePipelinesConfirms(t *testing.T) {
+	env.Env.EmitterBufferSize = 16
+	env.Env.BrokerMaxInFlight = 16
+
+	publisher := &fakePublisher{release: make(chan struct{}), confirmed: make(chan string, 16)}
+	queue := newBrokerQueue("fake", publisher)
+	for _, event := range []Wook{WookMessagesUpsert, WookMessagesUpdate, WookContactsUpsert} {
+		queue.enqueue(brokerEvent{instanceID: "a", event: event})
+	}
+
+	// every event is published while the first confirmation is still pending
+	deadline := time.Now().Add(time.Second)
+	for publisher.publishedCount() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("published %d events before the first confirmation, want 3", publisher.publishedCount())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(publisher.release)
+	for range 3 {
+		select {
+		case <-publisher.confirmed:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the confirmations")
+		}
+	}
+}
+
+func TestBrokerQueueRetriesNacks(t *testing.T) {
+	env.Env.EmitterBufferSize = 16
+	env.Env.BrokerMaxInFlight = 16
+
+	publisher := &fakePublisher{
+		fail:      map[string]bool{string(WookMessagesUpsert): true},
+		release:   make(chan struct{}),
+		confirmed: make(chan string, 16),
+	}
+	close(publisher.release)
+
+	queue := newBrokerQueue("fake", publisher)
+	queue.enqueue(brokerEvent{instanceID: "a", event: WookMessagesUpsert})
+
+	select {
+	case event := <-publisher.confirmed:
+		if event != string(WookMessagesUpsert) {
+			t.Fatalf("confirmed %s, want %s", event, WookMessagesUpsert)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for the retry")
+	}
+
+	if got := publisher.publishedCount(); got != 2 {
+		t.Errorf("published %d times, want 2", got)
+	}
+}
+
+// tooLargePublisher rejects every payload like SQS does with the ones over its limit
+type tooLargePublisher struct {
+	fakePublisher
+}
+
+func (p *tooLargePublisher) Publish(_ context.Context, _, event string, _ []byte) (interfaces.Confirm, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.published = append(p.published, event)
+	return nil, fmt.Errorf("%w: 300000 bytes", sqs.ErrPayloadTooLarge)
+}
+
+func TestBrokerQueueDropsTooLarge(t *testing.T) {
+	env.Env.EmitterBufferSize = 16
+	env.Env.BrokerMaxInFlight = 16
+
+	publisher := &tooLargePublisher{}
+	queue := newBrokerQueue("fake", publisher)
+	queue.enqueue(brokerEvent{instanceID: "a", event: WookMessagesUpsert})
+
+	// longer than the first backoff
+	time.Sleep(brokerBackoff(1) + 500*time.Millisecond)
+	if got := publisher.publishedCount(); got != 1 {
+		t.Errorf("published %d times, want 1", got)
+	}
+}
+
+func TestBrokerPayload(t *testing.T) {
+	media := &WookMessageRaw{Base64: "aGk=", ImageMessage: &WookImageMessageRaw{Mimetype: "image/jpeg"}, MediaURL: "https://storage/a.jpg"}
+	tests := []struct {
+		name  string
+		event any
+		want  any
+	}{
+		{
+			name:  "other events are unchanged",
+			event: &WookEvent[WookMessageUpdateData]{Instance: "a", Data: &WookMessageUpdateData{MessageId: "m"}},
+			want:  &WookEvent[WookMessageUpdateData]{Instance: "a", Data: &WookMessageUpdateData{MessageId: "m"}},
+		},
+		{
+			name:  "message without media is unchanged",
+			event: &WookEvent[WookMessageData]{Instance: "a", Data: &WookMessageData{Message: &WookMessageRaw{Conversation: "hi"}}},
+			want:  &WookEvent[WookMessageData]{Instance: "a", Data: &WookMessageData{Message: &WookMessageRaw{Conversation: "hi"}}},
+		},
+		{
+			name:  "base64 is removed",
+			event: &WookEvent[WookMessageData]{Instance: "a", Data: &WookMessageData{Message: media}},
+			want: &WookEvent[WookMessageData]{Instance: "a", Data: &WookMessageData{Message: &WookMessageRaw{
+				ImageMessage: &WookImageMessageRaw{Mimetype: "image/jpeg"},
+				MediaURL:     "https://storage/a.jpg",
+			}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.event)
+			if err != nil {
+				t.Fatalf("failed to marshal event: %v", err)
+			}
+
+			got, err := brokerPayload(tt.event, data)
+			if err != nil {
+				t.Fatalf("brokerPayload() error: %v", err)
+			}
+
+			want, _ := json.Marshal(tt.want)
+			if string(got) != string(want) {
+				t.Errorf("brokerPayload() = %s, want %s", got, want)
+			}
+		})
+	}
+
+	// the emitted event keeps the base64 for the webhook
+	if media.Base64 != "aGk=" {
+		t.Error("brokerPayload() removed the base64 of the emitted event")
+	}
+}
+
+func TestBrokerBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := brokerBackoff(tt.attempts); got != tt.want {
+			t.Errorf("brokerBackoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
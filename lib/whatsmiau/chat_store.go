@@ -0,0 +1,165 @@
+package whatsmiau
+
+import (
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+// updateChat applies the update to the stored chat when the message store is enabled
+func (s *Whatsmiau) updateChat(instanceID string, chat types.JID, update *models.ChatUpdate) {
+	if s.chatStore == nil || chat.Server == types.BroadcastServer {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	jid, lid := s.GetJidLid(ctx, instanceID, chat)
+	update.RemoteLid = lid
+
+	if err := s.chatStore.Update(ctx, instanceID, jid, update); err != nil {
+		zap.L().Error("failed to update stored chat", zap.String("instance", instanceID), zap.String("chat", jid), zap.Error(err))
+	}
+}
+
+// storeChatMessage sets the message as the last one of the chat, received messages count as unread
+// and sent ones mean the chat was read
+func (s *Whatsmiau) storeChatMessage(instanceID string, chat types.JID, data *WookMessageData) {
+	if s.chatStore == nil || data == nil || data.Key == nil {
+		return
+	}
+
+	update := &models.ChatUpdate{
+		LastMessage: chatMessage(data),
+	}
+
+	if data.Key.FromMe {
+		update.UnreadCount = intPtr(0)
+	} else {
+		update.AddUnread = 1
+		if chat.Server != types.GroupServer {
+			update.Name = data.PushName
+		}
+	}
+
+	s.updateChat(instanceID, chat, update)
+}
+
+// handleChatSettingEvent keeps the archived, pinned, muted and read state of the stored chats
+func (s *Whatsmiau) handleChatSettingEvent(id string, evt any) {
+	if s.chatStore == nil {
+		return
+	}
+
+	switch e := evt.(type) {
+	case *events.Archive:
+		s.updateChat(id, e.JID, &models.ChatUpdate{Archived: proto.Bool(e.Action.GetArchived())})
+	case *events.Pin:
+		s.updateChat(id, e.JID, &models.ChatUpdate{Pinned: proto.Bool(e.Action.GetPinned())})
+	case *events.Mute:
+		var muteEndTime int64
+		if e.Action.GetMuted() {
+			muteEndTime = e.Action.GetMuteEndTimestamp()
+		}
+		s.updateChat(id, e.JID, &models.ChatUpdate{MuteEndTime: &muteEndTime})
+	case *events.MarkChatAsRead:
+		// chats marked as unread keep their count
+		if e.Action.GetRead() {
+			s.updateChat(id, e.JID, &models.ChatUpdate{UnreadCount: intPtr(0)})
+		}
+	}
+}
+
+func chatMessage(data *WookMessageData) *models.ChatMessage {
+	return &models.ChatMessage{
+		ID:          data.Key.Id,
+		FromMe:      data.Key.FromMe,
+		MessageType: data.MessageType,
+		Text:        messagePreview(data.Message),
+		Timestamp:   time.Unix(int64(data.MessageTimestamp), 0),
+	}
+}
+
+// messagePreview returns the text shown in the chat list for the message
+func messagePreview(raw *WookMessageRaw) string {
+	switch {
+	case raw == nil:
+		return ""
+	case raw.Conversation != "":
+		return raw.Conversation
+	case raw.ImageMessage != nil:
+		return raw.ImageMessage.Caption
+	case raw.VideoMessage != nil:
+		return raw.VideoMessage.Caption
+	case raw.DocumentMessage != nil:
+		if raw.DocumentMessage.Caption != "" {
+			return raw.DocumentMessage.Caption
+		}
+		return raw.DocumentMessage.FileName
+	case raw.LocationMessage != nil:
+		return raw.LocationMessage.Name
+	case raw.ContactMessage != nil:
+		return raw.ContactMessage.DisplayName
+	case raw.ContactsArrayMessage != nil:
+		return raw.ContactsArrayMessage.DisplayName
+	case raw.PollCreationMessage != nil:
+		return raw.PollCreationMessage.Name
+	}
+
+	return ""
+}
+
+type FindChatsResult struct {
+	Total   int
+	Records []WookChat
+}
+
+// FindChats returns a page of the stored chats, pinned first and then by the last activity
+func (s *Whatsmiau) FindChats(ctx context.Context, filter *models.ChatFilter) (*FindChatsResult, error) {
+	if s.chatStore == nil {
+		return nil, ErrMessageStoreDisabled
+	}
+
+	chats, total, err := s.chatStore.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FindChatsResult{
+		Total:   total,
+		Records: make([]WookChat, 0, len(chats)),
+	}
+	for _, chat := range chats {
+		record := WookChat{
+			RemoteJid:             chat.RemoteJid,
+			RemoteLid:             chat.RemoteLid,
+			Name:                  chat.Name,
+			UnreadMessages:        chat.UnreadCount,
+			Archived:              chat.Archived,
+			Pinned:                chat.Pinned,
+			MuteEndTime:           int(chat.MuteEndTime),
+			ConversationTimestamp: int(chat.Timestamp.Unix()),
+			InstanceId:            chat.InstanceID,
+		}
+
+		if last := chat.LastMessage; last != nil {
+			record.LastMessage = &WookChatLastMessage{
+				Id:               last.ID,
+				FromMe:           last.FromMe,
+				MessageType:      last.MessageType,
+				Text:             last.Text,
+				MessageTimestamp: int(last.Timestamp.Unix()),
+			}
+		}
+
+		result.Records = append(result.Records, record)
+	}
+
+	return result, nil
+}
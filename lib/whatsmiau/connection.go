@@ -0,0 +1,152 @@
+package whatsmiau
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.uber.org/zap"
+)
+
+// connectionUpdate converts the whatsmeow connection events to the connection.update data, nil for other events
+func (s *Whatsmiau) connectionUpdate(id string, evt any) *WookConnectionUpdateData {
+	switch e := evt.(type) {
+	case *events.Connected:
+		data := &WookConnectionUpdateData{
+			State:        ConnectionStateOpen,
+			StatusReason: StatusReasonOpen,
+		}
+		if client, ok := s.clients.Load(id); ok && client.Store != nil && client.Store.ID != nil {
+			data.Wuid = client.Store.ID.ToNonAD().String()
+			data.ProfileName = client.Store.PushName
+		}
+		return data
+	case *events.Disconnected:
+		return &WookConnectionUpdateData{
+			State:        ConnectionStateClose,
+			StatusReason: StatusReasonConnectionClosed,
+			Reason:       "connection lost, reconnecting",
+		}
+	case *events.StreamReplaced:
+		return &WookConnectionUpdateData{
+			State:        ConnectionStateClose,
+			StatusReason: StatusReasonReplaced,
+			Reason:       "another client connected with the same session",
+		}
+	case *events.TemporaryBan:
+		data := &WookConnectionUpdateData{
+			State:        ConnectionStateClose,
+			StatusReason: StatusReasonBanned,
+			Reason:       e.String(),
+		}
+		if e.Expire > 0 {
+			expiresAt := time.Now().Add(e.Expire)
+			data.BanExpiresAt = &expiresAt
+		}
+		return data
+	case *events.ConnectFailure:
+		reason := e.Reason.String()
+		if e.Message != "" {
+			reason += ": " + e.Message
+		}
+		return &WookConnectionUpdateData{
+			State:        ConnectionStateClose,
+			StatusReason: int(e.Reason),
+			Reason:       reason,
+		}
+	case *events.LoggedOut:
+		data := &WookConnectionUpdateData{
+			State:        ConnectionStateClose,
+			StatusReason: StatusReasonLoggedOut,
+			Reason:       "logged out from the phone",
+		}
+		if e.OnConnect {
+			data.StatusReason = int(e.Reason)
+			data.Reason = e.Reason.String()
+		}
+		return data
+	}
+
+	return nil
+}
+
+// handleConnectionEvent emits the connection.update of the whatsmeow connection events
+func (s *Whatsmiau) handleConnectionEvent(id string, evt any) {
+	if data := s.connectionUpdate(id, evt); data != nil {
+		s.emitConnectionUpdate(id, data)
+	}
+}
+
+// emitConnectionUpdate looks up the instance itself because it is also called by the connection observer,
+// before the instance has an event handler
+func (s *Whatsmiau) emitConnectionUpdate(id string, data *WookConnectionUpdateData) {
+	instance := s.getInstanceCached(id)
+	if instance == nil || !s.enabledEvents(instance)["CONNECTION_UPDATE"] {
+		return
+	}
+
+	data.Instance = instance.ID
+	wookData := &WookEvent[WookConnectionUpdateData]{
+		Instance: instance.ID,
+		Data:     data,
+		DateTime: time.Now(),
+		Event:    WookConnectionUpdate,
+	}
+
+	s.emit(instance, wookData.Event, wookData)
+}
+
+// emitQRCodeUpdated sends the QR code as a png data URI with the pairing code, when it was requested
+func (s *Whatsmiau) emitQRCodeUpdated(id, code string) {
+	instance := s.getInstanceCached(id)
+	if instance == nil || !s.enabledEvents(instance)["QRCODE_UPDATED"] {
+		return
+	}
+
+	png, err := qrcode.Encode(code, qrcode.Medium, 512)
+	if err != nil {
+		zap.L().Error("failed to encode qrcode", zap.String("id", id), zap.Error(err))
+		return
+	}
+
+	data := &WookQRCodeUpdatedData{
+		QRCode: WookQRCode{
+			Instance: instance.ID,
+			Code:     code,
+			Base64:   "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+		},
+	}
+	if pairing, ok := s.pairingCodes.Load(id); ok {
+		data.QRCode.PairingCode = pairing.Code
+	}
+
+	wookData := &WookEvent[WookQRCodeUpdatedData]{
+		Instance: instance.ID,
+		Data:     data,
+		DateTime: time.Now(),
+		Event:    WookQRCodeUpdated,
+	}
+
+	s.emit(instance, wookData.Event, wookData)
+}
+
+func (s *Whatsmiau) emitLogout(id string, statusReason int, reason string) {
+	instance := s.getInstanceCached(id)
+	if instance == nil || !s.enabledEvents(instance)["LOGOUT_INSTANCE"] {
+		return
+	}
+
+	wookData := &WookEvent[WookLogoutData]{
+		Instance: instance.ID,
+		Data: &WookLogoutData{
+			Instance:     instance.ID,
+			StatusReason: statusReason,
+			Reason:       reason,
+		},
+		DateTime: time.Now(),
+		Event:    WookLogoutInstance,
+	}
+
+	s.emit(instance, wookData.Event, wookData)
+}
@@ -1,18 +1,19 @@
 package whatsmiau
 
 import (
-	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/emersion/go-vcard"
 	"github.com/google/uuid"
+	"github.com/verbeux-ai/whatsmiau/env"
 	"github.com/verbeux-ai/whatsmiau/models"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waE2E"
@@ -21,11 +22,13 @@ import (
 	"go.mau.fi/whatsmeow/types/events"
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
 )
 
 type emitter struct {
-	url  string
-	data any
+	instance *models.Instance
+	event    Wook
+	data     any
 }
 
 func (s *Whatsmiau) getInstance(id string) *models.Instance {
@@ -48,9 +51,19 @@ func (s *Whatsmiau) getInstance(id string) *models.Instance {
 }
 
 func (s *Whatsmiau) getInstanceCached(id string) *models.Instance {
+	instance, err := s.lookupInstanceCached(id)
+	if err != nil {
+		zap.L().Panic("failed to get instanceCached by instance", zap.Error(err))
+	}
+
+	return instance
+}
+
+// lookupInstanceCached is getInstanceCached for the background workers, which must not crash on a repository error
+func (s *Whatsmiau) lookupInstanceCached(id string) (*models.Instance, error) {
 	instanceCached, ok := s.instanceCache.Load(id)
 	if ok {
-		return &instanceCached
+		return &instanceCached, nil
 	}
 
 	ctx, c := context.WithTimeout(context.Background(), time.Second*5)
@@ -58,12 +71,12 @@ func (s *Whatsmiau) getInstanceCached(id string) *models.Instance {
 
 	res, err := s.repo.List(ctx, id)
 	if err != nil {
-		zap.L().Panic("failed to get instanceCached by instance", zap.Error(err))
+		return nil, err
 	}
 
 	if len(res) == 0 {
 		zap.L().Warn("no instanceCached found by instance", zap.String("instance", id))
-		return nil
+		return nil, nil
 	}
 
 	s.instanceCache.Store(id, res[0])
@@ -73,7 +86,7 @@ func (s *Whatsmiau) getInstanceCached(id string) *models.Instance {
 		s.instanceCache.Delete(id)
 	}()
 
-	return &res[0]
+	return &res[0], nil
 }
 
 func (s *Whatsmiau) startEmitter() {
@@ -81,40 +94,87 @@ func (s *Whatsmiau) startEmitter() {
 		data, err := json.Marshal(event.data)
 		if err != nil {
 			zap.L().Error("failed to marshal event", zap.Error(err))
-			return
+			continue
 		}
 
-		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, event.url, bytes.NewReader(data))
-		if err != nil {
-			zap.L().Error("failed to create request", zap.Error(err))
-			return
+		if webhookAllows(event.instance.Webhook, event.event) {
+			s.enqueueWebhook(event.instance, event.event, data)
 		}
 
-		req.Header.Set("Content-Type", "application/json")
-		resp, err := s.httpClient.Do(req)
-		if err != nil {
-			zap.L().Error("failed to send request", zap.Error(err))
-			return
+		toRabbitMQ := s.rabbitmq != nil && brokerAllows(event.instance.RabbitMQ, event.event)
+		toSQS := s.sqs != nil && brokerAllows(event.instance.SQS, event.event)
+		if toRabbitMQ || toSQS {
+			s.enqueueBrokers(event, data, toRabbitMQ, toSQS)
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			res, err := io.ReadAll(resp.Body)
-			if err != nil {
-				zap.L().Error("failed to read response body", zap.Error(err))
-			} else {
-				zap.L().Error("error doing request", zap.Any("response", string(res)), zap.String("url", event.url))
-			}
-		}
+		s.broadcast(event.instance.ID, event.event, data)
+	}
+}
+
+func (s *Whatsmiau) enqueueBrokers(event emitter, data []byte, toRabbitMQ, toSQS bool) {
+	brokerData, err := brokerPayload(event.data, data)
+	if err != nil {
+		zap.L().Error("failed to marshal broker event", zap.String("instance", event.instance.ID), zap.Error(err))
+		return
+	}
+
+	if toRabbitMQ {
+		s.rabbitmq.enqueue(brokerEvent{instanceID: event.instance.ID, event: event.event, data: brokerData})
+	}
+	if toSQS {
+		s.sqs.enqueue(brokerEvent{instanceID: event.instance.ID, event: event.event, data: brokerData})
+	}
+}
+
+func (s *Whatsmiau) enqueueWebhook(instance *models.Instance, event Wook, data []byte) {
+	ctx, c := context.WithTimeout(context.Background(), 5*time.Second)
+	defer c()
+
+	err := s.webhookQueue.Enqueue(ctx, &models.WebhookDelivery{
+		ID:         uuid.NewString(),
+		InstanceID: instance.ID,
+		Event:      string(event),
+		Url:        webhookUrl(instance.Webhook, event),
+		Payload:    data,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		zap.L().Error("failed to enqueue webhook", zap.String("instance", instance.ID), zap.String("event", string(event)), zap.Error(err))
+		return
+	}
+
+	s.wakeDeliveryWorker(instance.ID)
+}
+
+func (s *Whatsmiau) emit(instance *models.Instance, event Wook, body any) {
+	s.emitter <- emitter{
+		instance: instance,
+		event:    event,
+		data:     body,
 	}
 }
 
-func (s *Whatsmiau) emit(body any, url string) {
-	s.emitter <- emitter{url, body}
+func webhookAllows(webhook *models.InstanceWebhook, event Wook) bool {
+	if webhook == nil || len(webhook.Url) == 0 {
+		return false
+	}
+
+	return slices.Contains(webhook.Events, event.Name())
+}
+
+// webhookUrl appends the event path to the url when byEvents is enabled (Evolution compatible),
+// ex: messages.upsert is posted to <url>/messages-upsert
+func webhookUrl(webhook *models.InstanceWebhook, event Wook) string {
+	if webhook.ByEvents == nil || !*webhook.ByEvents {
+		return webhook.Url
+	}
+
+	return strings.TrimSuffix(webhook.Url, "/") + "/" + event.Path()
 }
 
 func (s *Whatsmiau) Handle(id string) whatsmeow.EventHandler {
 	return func(evt any) {
+		s.trackConnection(id, evt)
 		s.handlerSemaphore <- struct{}{}
 		go func() {
 			defer func() { <-s.handlerSemaphore }()
@@ -124,14 +184,16 @@ func (s *Whatsmiau) Handle(id string) whatsmeow.EventHandler {
 				return
 			}
 
-			eventMap := make(map[string]bool)
-			for _, event := range instance.Webhook.Events {
-				eventMap[event] = true
-			}
+			eventMap := s.enabledEvents(instance)
 
 			switch e := evt.(type) {
 			case *events.LoggedOut:
-				s.handleLoggedOut(id)
+				s.handleLoggedOut(id, e)
+			case *events.Connected:
+				s.handleConnectedEvent(id, instance)
+				s.handleConnectionEvent(id, e)
+			case *events.Disconnected, *events.StreamReplaced, *events.TemporaryBan, *events.ConnectFailure:
+				s.handleConnectionEvent(id, e)
 			case *events.Message:
 				s.handleMessageEvent(id, instance, e, eventMap)
 			case *events.Receipt:
@@ -146,8 +208,14 @@ func (s *Whatsmiau) Handle(id string) whatsmeow.EventHandler {
 				s.handleHistorySyncEvent(id, instance, e, eventMap)
 			case *events.GroupInfo:
 				s.handleGroupInfoEvent(id, instance, e, eventMap)
+			case *events.JoinedGroup:
+				s.handleJoinedGroupEvent(id, instance, e, eventMap)
 			case *events.PushName:
 				s.handlePushNameEvent(id, instance, e, eventMap)
+			case *events.CallOffer:
+				s.handleCallOfferEvent(id, instance, e, eventMap)
+			case *events.Archive, *events.Pin, *events.Mute, *events.MarkChatAsRead:
+				s.handleChatSettingEvent(id, e)
 			default:
 				zap.L().Debug("unknown event", zap.String("type", fmt.Sprintf("%T", evt)), zap.Any("raw", evt))
 			}
@@ -155,7 +223,12 @@ func (s *Whatsmiau) Handle(id string) whatsmeow.EventHandler {
 	}
 }
 
-func (s *Whatsmiau) handleLoggedOut(id string) {
+func (s *Whatsmiau) handleLoggedOut(id string, e *events.LoggedOut) {
+	data := s.connectionUpdate(id, e)
+	s.emitConnectionUpdate(id, data)
+	s.emitLogout(id, data.StatusReason, data.Reason)
+	s.stopSupervisor(id)
+
 	client, ok := s.clients.Load(id)
 	if ok {
 		if err := s.deleteDeviceIfExists(context.Background(), client); err != nil {
@@ -165,28 +238,44 @@ func (s *Whatsmiau) handleLoggedOut(id string) {
 	}
 
 	s.clients.Delete(id)
+	s.messageCache.Delete(id)
 }
 
 func (s *Whatsmiau) handleMessageEvent(id string, instance *models.Instance, e *events.Message, eventMap map[string]bool) {
-	if !eventMap["MESSAGES_UPSERT"] {
+	if isEditOrRevoke(e.Message.GetProtocolMessage()) {
+		s.handleProtocolMessage(id, instance, e, eventMap)
 		return
 	}
 
+	s.cacheReceivedMessage(id, e)
+
 	if canIgnoreGroup(e, instance) {
 		return
 	}
 
+	s.autoRead(id, instance, e)
+
+	if !eventMap["MESSAGES_UPSERT"] && s.messageStore == nil {
+		return
+	}
+
 	if canIgnoreMessage(e) {
 		return
 	}
 
-	messageData := s.convertEventMessage(id, instance, e)
+	messageData := s.convertEventMessage(id, instance, e, true)
 	if messageData == nil {
 		zap.L().Error("failed to convert event", zap.String("id", id), zap.String("type", fmt.Sprintf("%T", e)), zap.Any("raw", e))
 		return
 	}
 
 	messageData.InstanceId = instance.ID
+	s.storeMessage(messageData)
+	s.storeChatMessage(id, e.Info.Chat, messageData)
+
+	if !eventMap["MESSAGES_UPSERT"] {
+		return
+	}
 
 	dateTime := time.Unix(int64(messageData.MessageTimestamp), 0)
 	wookMessage := &WookEvent[WookMessageData]{
@@ -205,7 +294,7 @@ func (s *Whatsmiau) handleMessageEvent(id string, instance *models.Instance, e *
 		zap.L().Debug("message event", zap.String("instance", id), zap.Any("data", wookMessage.Data))
 	}
 
-	s.emit(wookMessage, instance.Webhook.Url)
+	s.emit(instance, wookMessage.Event, wookMessage)
 
 	if s.chatwootService != nil {
 		go s.chatwootService.HandleMessage(messageData)
@@ -213,7 +302,7 @@ func (s *Whatsmiau) handleMessageEvent(id string, instance *models.Instance, e *
 }
 
 func (s *Whatsmiau) handleReceiptEvent(id string, instance *models.Instance, e *events.Receipt, eventMap map[string]bool) {
-	if !eventMap["MESSAGES_UPDATE"] {
+	if !eventMap["MESSAGES_UPDATE"] && s.messageStore == nil {
 		return
 	}
 
@@ -221,12 +310,23 @@ func (s *Whatsmiau) handleReceiptEvent(id string, instance *models.Instance, e *
 		return
 	}
 
+	// the instance read the chat in another device
+	if e.IsFromMe && (e.Type == types.ReceiptTypeRead || e.Type == types.ReceiptTypeReadSelf) {
+		s.updateChat(id, e.Chat, &models.ChatUpdate{UnreadCount: intPtr(0)})
+	}
+
 	data := s.convertEventReceipt(id, e)
 	if data == nil {
 		return
 	}
 
 	for _, event := range data {
+		s.storeMessageStatus(&event)
+
+		if !eventMap["MESSAGES_UPDATE"] {
+			continue
+		}
+
 		wookData := &WookEvent[WookMessageUpdateData]{
 			Instance: instance.ID,
 			Data:     &event,
@@ -234,8 +334,44 @@ func (s *Whatsmiau) handleReceiptEvent(id string, instance *models.Instance, e *
 			Event:    WookMessagesUpdate,
 		}
 
-		s.emit(wookData, instance.Webhook.Url)
+		s.emit(instance, wookData.Event, wookData)
+	}
+}
+
+func isEditOrRevoke(pm *waE2E.ProtocolMessage) bool {
+	return pm.GetType() == waE2E.ProtocolMessage_MESSAGE_EDIT || pm.GetType() == waE2E.ProtocolMessage_REVOKE
+}
+
+// handleProtocolMessage emits edits as messages.update and revokes as messages.delete
+func (s *Whatsmiau) handleProtocolMessage(id string, instance *models.Instance, e *events.Message, eventMap map[string]bool) {
+	event := WookMessagesUpdate
+	if e.Message.GetProtocolMessage().GetType() == waE2E.ProtocolMessage_REVOKE {
+		event = WookMessagesDelete
+	}
+
+	if !eventMap[event.Name()] && s.messageStore == nil {
+		return
+	}
+
+	if canIgnoreGroup(e, instance) {
+		return
+	}
+
+	data := s.convertProtocolMessage(id, e)
+	s.storeMessageStatus(data)
+
+	if !eventMap[event.Name()] {
+		return
+	}
+
+	wookData := &WookEvent[WookMessageUpdateData]{
+		Instance: instance.ID,
+		Data:     data,
+		DateTime: e.Info.Timestamp,
+		Event:    event,
 	}
+
+	s.emit(instance, wookData.Event, wookData)
 }
 
 func (s *Whatsmiau) handleBusinessNameEvent(id string, instance *models.Instance, e *events.BusinessName, eventMap map[string]bool) {
@@ -256,7 +392,7 @@ func (s *Whatsmiau) handleBusinessNameEvent(id string, instance *models.Instance
 		Event:    WookContactsUpsert,
 	}
 
-	s.emit(wookData, instance.Webhook.Url)
+	s.emit(instance, wookData.Event, wookData)
 }
 
 func (s *Whatsmiau) handleContactEvent(id string, instance *models.Instance, e *events.Contact, eventMap map[string]bool) {
@@ -281,7 +417,7 @@ func (s *Whatsmiau) handleContactEvent(id string, instance *models.Instance, e *
 		Event:    WookContactsUpsert,
 	}
 
-	s.emit(wookData, instance.Webhook.Url)
+	s.emit(instance, wookData.Event, wookData)
 }
 
 func (s *Whatsmiau) handlePictureEvent(id string, instance *models.Instance, e *events.Picture, eventMap map[string]bool) {
@@ -301,10 +437,13 @@ func (s *Whatsmiau) handlePictureEvent(id string, instance *models.Instance, e *
 		Event:    WookContactsUpsert,
 	}
 
-	s.emit(wookData, instance.Webhook.Url)
+	s.emit(instance, wookData.Event, wookData)
 }
 
 func (s *Whatsmiau) handleHistorySyncEvent(id string, instance *models.Instance, e *events.HistorySync, eventMap map[string]bool) {
+	// every pairing receives the recent history, SyncFullHistory only asks for more of it
+	s.handleHistoryConversations(id, instance, e, eventMap)
+
 	if !eventMap["CONTACTS_UPSERT"] {
 		return
 	}
@@ -321,10 +460,157 @@ func (s *Whatsmiau) handleHistorySyncEvent(id string, instance *models.Instance,
 		Event:    WookContactsUpsert,
 	}
 
-	s.emit(wookData, instance.Webhook.Url)
+	s.emit(instance, wookData.Event, wookData)
+}
+
+// historyBatchSize is how many messages or chats each messages.set and chats.set event carries
+const historyBatchSize = 100
+
+// isContentMessage returns true for the messages shown in the chat. The history also has the ones that only
+// change others (reactions, votes, edits, pins...) and the ones without content (key distributions, stubs)
+func isContentMessage(msg *waE2E.Message) bool {
+	if msg == nil ||
+		msg.ProtocolMessage != nil ||
+		msg.ReactionMessage != nil ||
+		msg.EncReactionMessage != nil ||
+		msg.PollUpdateMessage != nil ||
+		msg.EncEventResponseMessage != nil ||
+		msg.KeepInChatMessage != nil ||
+		msg.PinInChatMessage != nil {
+		return false
+	}
+
+	return msg.GetConversation() != "" || messageContent(msg) != nil
+}
+
+// handleHistoryConversations emits the chats (chats.set) and messages (messages.set) of the history
+// sync in batches and stores the messages
+func (s *Whatsmiau) handleHistoryConversations(id string, instance *models.Instance, e *events.HistorySync, eventMap map[string]bool) {
+	if !eventMap["MESSAGES_SET"] && !eventMap["CHATS_SET"] && s.messageStore == nil {
+		return
+	}
+
+	client, ok := s.clients.Load(id)
+	if !ok {
+		zap.L().Warn("no client for event", zap.String("id", id))
+		return
+	}
+
+	var (
+		chats    WookChatsSetData
+		messages WookMessagesSetData
+	)
+
+	flushMessages := func() {
+		if eventMap["MESSAGES_SET"] && len(messages) > 0 {
+			batch := messages
+			s.emit(instance, WookMessagesSet, &WookEvent[WookMessagesSetData]{
+				Instance: instance.ID,
+				Data:     &batch,
+				DateTime: time.Now(),
+				Event:    WookMessagesSet,
+			})
+		}
+		messages = nil
+	}
+
+	flushChats := func() {
+		if eventMap["CHATS_SET"] && len(chats) > 0 {
+			batch := chats
+			s.emit(instance, WookChatsSet, &WookEvent[WookChatsSetData]{
+				Instance: instance.ID,
+				Data:     &batch,
+				DateTime: time.Now(),
+				Event:    WookChatsSet,
+			})
+		}
+		chats = nil
+	}
+
+	for _, conversation := range e.Data.GetConversations() {
+		chatJID, err := types.ParseJID(conversation.GetID())
+		if err != nil {
+			zap.L().Debug("invalid history conversation jid", zap.String("id", id), zap.String("jid", conversation.GetID()))
+			continue
+		}
+
+		if chatJID.Server == types.BroadcastServer || (instance.GroupsIgnore && chatJID.Server == types.GroupServer) {
+			continue
+		}
+
+		chat := s.convertHistoryChat(id, chatJID, conversation)
+		chats = append(chats, chat)
+		if len(chats) >= historyBatchSize {
+			flushChats()
+		}
+
+		var last *WookMessageData
+		for _, historyMessage := range conversation.GetMessages() {
+			evt, err := client.ParseWebMessage(chatJID, historyMessage.GetMessage())
+			if err != nil {
+				zap.L().Debug("failed to parse history message", zap.String("id", id), zap.Error(err))
+				continue
+			}
+
+			if !isContentMessage(evt.Message) {
+				continue
+			}
+
+			data := s.convertEventMessage(id, instance, evt, false)
+			if data == nil {
+				continue
+			}
+
+			data.InstanceId = instance.ID
+			s.storeMessage(data)
+			if last == nil || data.MessageTimestamp > last.MessageTimestamp {
+				last = data
+			}
+
+			messages = append(messages, *data)
+			if len(messages) >= historyBatchSize {
+				flushMessages()
+			}
+		}
+
+		s.updateChat(id, chatJID, historyChatUpdate(chat, last))
+	}
+
+	flushChats()
+	flushMessages()
 }
 
 func (s *Whatsmiau) handleGroupInfoEvent(id string, instance *models.Instance, e *events.GroupInfo, eventMap map[string]bool) {
+	if e.Name != nil {
+		s.updateChat(id, e.JID, &models.ChatUpdate{Name: e.Name.Name})
+	}
+
+	if eventMap["GROUPS_UPDATE"] {
+		if data := s.convertGroupUpdate(id, e); data != nil {
+			wookData := &WookEvent[WookGroupsUpdateData]{
+				Instance: instance.ID,
+				Data:     &WookGroupsUpdateData{*data},
+				DateTime: time.Now(),
+				Event:    WookGroupsUpdate,
+			}
+
+			s.emit(instance, wookData.Event, wookData)
+		}
+	}
+
+	if eventMap["GROUP_PARTICIPANTS_UPDATE"] {
+		for _, data := range s.convertGroupParticipantsUpdate(id, e) {
+			wookData := &WookEvent[WookGroupParticipantsUpdateData]{
+				Instance: instance.ID,
+				Data:     &data,
+				DateTime: time.Now(),
+				Event:    WookGroupParticipantsUpdate,
+			}
+
+			s.emit(instance, wookData.Event, wookData)
+		}
+	}
+
 	if !eventMap["CONTACTS_UPSERT"] {
 		return
 	}
@@ -346,7 +632,31 @@ func (s *Whatsmiau) handleGroupInfoEvent(id string, instance *models.Instance, e
 		Event:    WookContactsUpsert,
 	}
 
-	s.emit(wookData, instance.Webhook.Url)
+	s.emit(instance, wookData.Event, wookData)
+}
+
+// handleJoinedGroupEvent is triggered when the instance creates or is added to a group
+func (s *Whatsmiau) handleJoinedGroupEvent(id string, instance *models.Instance, e *events.JoinedGroup, eventMap map[string]bool) {
+	s.updateChat(id, e.JID, &models.ChatUpdate{Name: e.Name, Timestamp: time.Now()})
+
+	if !eventMap["GROUPS_UPSERT"] {
+		return
+	}
+
+	author, authorLid := s.groupAuthor(id, e.Sender, e.SenderPN)
+	wookData := &WookEvent[WookGroupsUpsertData]{
+		Instance: instance.ID,
+		Data: &WookGroupsUpsertData{{
+			Group:      convertGroup(&e.GroupInfo, true),
+			Author:     author,
+			AuthorLid:  authorLid,
+			InstanceId: instance.ID,
+		}},
+		DateTime: time.Now(),
+		Event:    WookGroupsUpsert,
+	}
+
+	s.emit(instance, wookData.Event, wookData)
 }
 
 func (s *Whatsmiau) handlePushNameEvent(id string, instance *models.Instance, e *events.PushName, eventMap map[string]bool) {
@@ -371,7 +681,78 @@ func (s *Whatsmiau) handlePushNameEvent(id string, instance *models.Instance, e
 		Event:    WookContactsUpsert,
 	}
 
-	s.emit(wookData, instance.Webhook.Url)
+	s.emit(instance, wookData.Event, wookData)
+}
+
+func (s *Whatsmiau) handleCallOfferEvent(id string, instance *models.Instance, e *events.CallOffer, eventMap map[string]bool) {
+	caller := e.CallCreator
+	if caller.IsEmpty() {
+		caller = e.From
+	}
+
+	status := CallStatusOffer
+	if instance.RejectCall {
+		if err := s.rejectCall(id, instance, e.From, caller, e.CallID); err != nil {
+			zap.L().Error("failed to reject call", zap.String("id", id), zap.String("call", e.CallID), zap.Error(err))
+		} else {
+			status = CallStatusReject
+		}
+	}
+
+	if !eventMap["CALL"] {
+		return
+	}
+
+	callerJid, callerLid := s.GetJidLid(context.Background(), id, caller)
+	_, isVideo := e.Data.GetOptionalChildByTag("video")
+
+	wookData := &WookEvent[WookCallData]{
+		Instance: instance.ID,
+		Data: &WookCallData{
+			Id:         e.CallID,
+			From:       callerJid,
+			FromLid:    callerLid,
+			IsGroup:    !e.GroupJID.IsEmpty(),
+			IsVideo:    isVideo,
+			Status:     status,
+			Date:       e.Timestamp,
+			InstanceId: instance.ID,
+		},
+		DateTime: e.Timestamp,
+		Event:    WookCall,
+	}
+	if !e.GroupJID.IsEmpty() {
+		wookData.Data.GroupJid = e.GroupJID.String()
+	}
+
+	s.emit(instance, wookData.Event, wookData)
+}
+
+// rejectCall rejects the call and replies the caller with the instance MsgCall, if any
+func (s *Whatsmiau) rejectCall(id string, instance *models.Instance, from, caller types.JID, callID string) error {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return whatsmeow.ErrClientIsNil
+	}
+
+	ctx, c := context.WithTimeout(context.Background(), 10*time.Second)
+	defer c()
+
+	if err := client.RejectCall(ctx, from, callID); err != nil {
+		return err
+	}
+
+	if len(instance.MsgCall) == 0 {
+		return nil
+	}
+
+	if _, err := client.SendMessage(ctx, caller.ToNonAD(), &waE2E.Message{
+		Conversation: proto.String(instance.MsgCall),
+	}); err != nil {
+		zap.L().Error("failed to send call message", zap.String("id", id), zap.String("call", callID), zap.Error(err))
+	}
+
+	return nil
 }
 
 // parseWAMessage converts a raw waE2E.Message into our internal representation.
@@ -403,12 +784,70 @@ func (s *Whatsmiau) parseWAMessage(m *waE2E.Message) (string, *WookMessageRaw, *
 		if ssr := lr.GetSingleSelectReply(); ssr != nil {
 			selectedRowID = ssr.GetSelectedRowID()
 		}
+		ci = lr.GetContextInfo()
 		raw.ListResponseMessage = &WookListMessageRaw{
-			ListType: listType,
+			Title:       lr.GetTitle(),
+			Description: lr.GetDescription(),
+			ListType:    listType,
 			SingleSelectReply: &WookListMessageRawListSingleSelectReply{
 				SelectedRowId: selectedRowID,
 			},
 		}
+
+		// the quoted list has the rows, used when the reply doesn't bring the row title/description
+		if list := ci.GetQuotedMessage().GetListMessage(); list != nil {
+			quotedList := convertListMessage(list)
+			raw.ListResponseMessage.ContextInfo = &WookListMessageRawListContextInfo{
+				StanzaId:    ci.GetStanzaID(),
+				Participant: ci.GetParticipant(),
+				QuotedMessage: &WookListMessageRawListContextInfoMessage{
+					ListMessage: quotedList,
+				},
+			}
+
+			for _, section := range quotedList.Sections {
+				for _, row := range section.Rows {
+					if row.RowId != selectedRowID {
+						continue
+					}
+					if raw.ListResponseMessage.Title == "" {
+						raw.ListResponseMessage.Title = row.Title
+					}
+					if raw.ListResponseMessage.Description == "" {
+						raw.ListResponseMessage.Description = row.Description
+					}
+				}
+			}
+		}
+	} else if br := m.GetButtonsResponseMessage(); br != nil {
+		messageType = "buttonsResponseMessage"
+		ci = br.GetContextInfo()
+		raw.ButtonsResponseMessage = &WookButtonsResponseMessageRaw{
+			SelectedButtonId:    br.GetSelectedButtonID(),
+			SelectedDisplayText: br.GetSelectedDisplayText(),
+			Type:                br.GetType().String(),
+		}
+	} else if ir := m.GetInteractiveResponseMessage(); ir != nil {
+		messageType = "interactiveResponseMessage"
+		ci = ir.GetContextInfo()
+		raw.InteractiveResponseMessage = &WookInteractiveResponseMessageRaw{
+			Body: &WookInteractiveResponseBody{
+				Text:   ir.GetBody().GetText(),
+				Format: ir.GetBody().GetFormat().String(),
+			},
+		}
+		if nf := ir.GetNativeFlowResponseMessage(); nf != nil {
+			var params struct {
+				ID string `json:"id"`
+			}
+			_ = json.Unmarshal([]byte(nf.GetParamsJSON()), &params)
+			raw.InteractiveResponseMessage.NativeFlowResponseMessage = &WookNativeFlowResponseMessageRaw{
+				Name:       nf.GetName(),
+				ParamsJson: nf.GetParamsJSON(),
+				Version:    int(nf.GetVersion()),
+				SelectedId: params.ID,
+			}
+		}
 	} else if img := m.GetImageMessage(); img != nil {
 		messageType = "imageMessage"
 		ci = img.GetContextInfo()
@@ -509,6 +948,43 @@ func (s *Whatsmiau) parseWAMessage(m *waE2E.Message) (string, *WookMessageRaw, *
 			Contacts:    contacts,
 		}
 		ci = contactArray.GetContextInfo()
+	} else if loc := m.GetLocationMessage(); loc != nil {
+		messageType = "locationMessage"
+		ci = loc.GetContextInfo()
+		raw.LocationMessage = &WookLocationMessageRaw{
+			DegreesLatitude:  loc.GetDegreesLatitude(),
+			DegreesLongitude: loc.GetDegreesLongitude(),
+			Name:             loc.GetName(),
+			Address:          loc.GetAddress(),
+			Url:              loc.GetURL(),
+			JpegThumbnail:    b64(loc.GetJPEGThumbnail()),
+		}
+	} else if poll := pollCreation(m); poll != nil {
+		messageType = "pollCreationMessage"
+		ci = poll.GetContextInfo()
+		raw.PollCreationMessage = &WookPollCreationMessageRaw{
+			Name:                   poll.GetName(),
+			SelectableOptionsCount: int(poll.GetSelectableOptionsCount()),
+		}
+		for _, option := range poll.GetOptions() {
+			raw.PollCreationMessage.Options = append(raw.PollCreationMessage.Options, WookPollOption{
+				OptionName: option.GetOptionName(),
+			})
+		}
+	} else if pu := m.GetPollUpdateMessage(); pu != nil {
+		messageType = "pollUpdateMessage"
+		pollKey := &WookKey{}
+		if pk := pu.GetPollCreationMessageKey(); pk != nil {
+			pollKey.RemoteJid = pk.GetRemoteJID()
+			pollKey.FromMe = pk.GetFromMe()
+			pollKey.Id = pk.GetID()
+			pollKey.Participant = pk.GetParticipant()
+		}
+		// the vote is encrypted with the poll secret, filled by convertEventMessage
+		raw.PollUpdateMessage = &WookPollUpdateMessageRaw{
+			PollCreationMessageKey: pollKey,
+			SenderTimestampMs:      i64(pu.GetSenderTimestampMS()),
+		}
 	} else if conv := strings.TrimSpace(m.GetConversation()); conv != "" {
 		messageType = "conversation"
 		raw.Conversation = conv
@@ -523,6 +999,88 @@ func (s *Whatsmiau) parseWAMessage(m *waE2E.Message) (string, *WookMessageRaw, *
 	return messageType, raw, ci
 }
 
+// pollCreation returns the poll of any of the poll creation versions
+func pollCreation(m *waE2E.Message) *waE2E.PollCreationMessage {
+	if poll := m.GetPollCreationMessage(); poll != nil {
+		return poll
+	}
+	if poll := m.GetPollCreationMessageV2(); poll != nil {
+		return poll
+	}
+	if poll := m.GetPollCreationMessageV3(); poll != nil {
+		return poll
+	}
+	return m.GetPollCreationMessageV5()
+}
+
+func convertListMessage(list *waE2E.ListMessage) *WookListMessageRawListContextInfoMessageList {
+	result := &WookListMessageRawListContextInfoMessageList{
+		Title:       list.GetTitle(),
+		Description: list.GetDescription(),
+		ButtonText:  list.GetButtonText(),
+		ListType:    list.GetListType().String(),
+		FooterText:  list.GetFooterText(),
+	}
+
+	for _, section := range list.GetSections() {
+		wookSection := WookListSection{Title: section.GetTitle()}
+		for _, row := range section.GetRows() {
+			wookSection.Rows = append(wookSection.Rows, WookListRow{
+				Title:       row.GetTitle(),
+				Description: row.GetDescription(),
+				RowId:       row.GetRowID(),
+			})
+		}
+		result.Sections = append(result.Sections, wookSection)
+	}
+
+	return result
+}
+
+func (s *Whatsmiau) convertHistoryChat(id string, chatJID types.JID, conversation *waHistorySync.Conversation) WookChat {
+	jid, lid := s.GetJidLid(context.Background(), id, chatJID)
+
+	name := conversation.GetName()
+	if len(name) == 0 {
+		name = conversation.GetDisplayName()
+	}
+
+	timestamp := conversation.GetConversationTimestamp()
+	if timestamp == 0 {
+		timestamp = conversation.GetLastMsgTimestamp()
+	}
+
+	return WookChat{
+		RemoteJid:             jid,
+		RemoteLid:             lid,
+		Name:                  name,
+		UnreadMessages:        int(conversation.GetUnreadCount()),
+		Archived:              conversation.GetArchived(),
+		Pinned:                conversation.GetPinned() > 0,
+		ReadOnly:              conversation.GetReadOnly(),
+		MuteEndTime:           int(conversation.GetMuteEndTime()),
+		ConversationTimestamp: int(timestamp),
+		InstanceId:            id,
+	}
+}
+
+func historyChatUpdate(chat WookChat, last *WookMessageData) *models.ChatUpdate {
+	update := &models.ChatUpdate{
+		Name:        chat.Name,
+		Timestamp:   time.Unix(int64(chat.ConversationTimestamp), 0),
+		UnreadCount: intPtr(chat.UnreadMessages),
+		Archived:    proto.Bool(chat.Archived),
+		Pinned:      proto.Bool(chat.Pinned),
+		MuteEndTime: proto.Int64(int64(chat.MuteEndTime)),
+	}
+
+	if last != nil {
+		update.LastMessage = chatMessage(last)
+	}
+
+	return update
+}
+
 func (s *Whatsmiau) convertContactHistorySync(id string, event []*waHistorySync.Pushname, conversations []*waHistorySync.Conversation) WookContactUpsertData {
 	resultMap := make(map[string]WookContact)
 	for _, pushName := range event {
@@ -591,7 +1149,7 @@ func (s *Whatsmiau) convertContactHistorySync(id string, event []*waHistorySync.
 	return result
 }
 
-func (s *Whatsmiau) convertEventMessage(id string, instance *models.Instance, evt *events.Message) *WookMessageData {
+func (s *Whatsmiau) convertEventMessage(id string, instance *models.Instance, evt *events.Message, withMedia bool) *WookMessageData {
 	ctx, c := context.WithTimeout(context.Background(), time.Second*60)
 	defer c()
 
@@ -631,23 +1189,32 @@ func (s *Whatsmiau) convertEventMessage(id string, instance *models.Instance, ev
 
 	messageType, raw, ci := s.parseWAMessage(m)
 
-	// Upload media (URL / Base64) when needed
 	switch messageType {
-	case "imageMessage":
-		if img := m.GetImageMessage(); img != nil {
-			raw.MediaURL, raw.Base64 = s.uploadMessageFile(ctx, instance, client, img, img.GetMimetype(), "")
-		}
-	case "audioMessage":
-		if aud := m.GetAudioMessage(); aud != nil {
-			raw.MediaURL, raw.Base64 = s.uploadMessageFile(ctx, instance, client, aud, aud.GetMimetype(), "")
-		}
-	case "documentMessage":
-		if doc := m.GetDocumentMessage(); doc != nil {
-			raw.MediaURL, raw.Base64 = s.uploadMessageFile(ctx, instance, client, doc, doc.GetMimetype(), doc.GetFileName())
-		}
-	case "videoMessage":
-		if vid := m.GetVideoMessage(); vid != nil {
-			raw.MediaURL, raw.Base64 = s.uploadMessageFile(ctx, instance, client, vid, vid.GetMimetype(), "")
+	case "pollCreationMessage":
+		s.storePollOptions(ctx, id, e.Info.ID, raw.PollCreationMessage.Options)
+	case "pollUpdateMessage":
+		raw.PollUpdateMessage.Vote = s.decryptPollVote(ctx, id, client, e)
+	}
+
+	// Upload media (URL / Base64) when needed, history sync messages are too many to be downloaded
+	if withMedia {
+		switch messageType {
+		case "imageMessage":
+			if img := m.GetImageMessage(); img != nil {
+				raw.MediaURL, raw.Base64 = s.uploadMessageFile(instance, client, img, img.GetMimetype(), "")
+			}
+		case "audioMessage":
+			if aud := m.GetAudioMessage(); aud != nil {
+				raw.MediaURL, raw.Base64 = s.uploadMessageFile(instance, client, aud, aud.GetMimetype(), "")
+			}
+		case "documentMessage":
+			if doc := m.GetDocumentMessage(); doc != nil {
+				raw.MediaURL, raw.Base64 = s.uploadMessageFile(instance, client, doc, doc.GetMimetype(), doc.GetFileName())
+			}
+		case "videoMessage":
+			if vid := m.GetVideoMessage(); vid != nil {
+				raw.MediaURL, raw.Base64 = s.uploadMessageFile(instance, client, vid, vid.GetMimetype(), "")
+			}
 		}
 	}
 
@@ -718,6 +1285,8 @@ func (s *Whatsmiau) convertEventReceipt(id string, evt *events.Receipt) []WookMe
 		status = MessageStatusRead
 	case types.ReceiptTypeDelivered:
 		status = MessageStatusDeliveryAck
+	case types.ReceiptTypePlayed:
+		status = MessageStatusPlayed
 	default:
 		return nil
 	}
@@ -742,21 +1311,85 @@ func (s *Whatsmiau) convertEventReceipt(id string, evt *events.Receipt) []WookMe
 	return result
 }
 
-func (s *Whatsmiau) uploadMessageFile(ctx context.Context, instance *models.Instance, client *whatsmeow.Client, fileMessage whatsmeow.DownloadableMessage, mimetype, fileName string) (string, string) {
+func (s *Whatsmiau) convertProtocolMessage(id string, evt *events.Message) *WookMessageUpdateData {
+	pm := evt.Message.GetProtocolMessage()
+	key := pm.GetKey()
+
+	chatJid, chatLid := s.GetJidLid(context.Background(), id, evt.Info.Chat)
+
+	// admins deleting messages of others send the original sender as the key participant
+	sender := evt.Info.Sender
+	if participant, err := types.ParseJID(key.GetParticipant()); err == nil && !participant.IsEmpty() {
+		sender = participant
+	}
+	participantJid, participantLid := s.GetJidLid(context.Background(), id, sender)
+
+	result := &WookMessageUpdateData{
+		MessageId:      key.GetID(),
+		KeyId:          key.GetID(),
+		RemoteJid:      chatJid,
+		RemoteLid:      chatLid,
+		FromMe:         evt.Info.IsFromMe && key.GetFromMe(),
+		Participant:    participantJid,
+		ParticipantLid: participantLid,
+		Status:         MessageStatusDeleted,
+		InstanceId:     id,
+	}
+
+	if pm.GetType() == waE2E.ProtocolMessage_MESSAGE_EDIT {
+		result.Status = MessageStatusEdited
+		result.MessageType, result.Message, _ = s.parseWAMessage(pm.GetEditedMessage())
+	}
+
+	return result
+}
+
+// uploadMessageFile downloads the media to a temp file and, when enabled, uploads it to the storage and
+// encodes it as base64 streaming from disk. Its own timeouts are used since big files take longer than the event
+func (s *Whatsmiau) uploadMessageFile(instance *models.Instance, client *whatsmeow.Client, fileMessage whatsmeow.DownloadableMessage, mimetype, fileName string) (string, string) {
 	var (
 		b64Result string
 		urlResult string
 		ext       string
 	)
 
+	var size uint64
+	if sized, ok := fileMessage.(interface{ GetFileLength() uint64 }); ok {
+		size = sized.GetFileLength()
+	}
+
+	// the base64 is kept in memory until the webhook is sent, bigger media only gets the storage url and
+	// instances without a webhook (brokers or streams only) never get it
+	withBase64 := instance.Webhook != nil && instance.Webhook.Base64 != nil && *instance.Webhook.Base64 && size <= uint64(env.Env.MediaBase64MaxSize)
+	if !withBase64 && s.fileStorage == nil {
+		return "", ""
+	}
+
+	if size > uint64(env.Env.MediaMaxSize) {
+		zap.L().Warn("media exceeds the max size, skipping", zap.String("instance", instance.ID), zap.Uint64("bytes", size))
+		return "", ""
+	}
+
+	downloadCtx, cancel := context.WithTimeout(context.Background(), env.Env.MediaDownloadTimeout)
+	defer cancel()
+
+	release, err := s.acquireMedia(downloadCtx)
+	if err != nil {
+		zap.L().Error("failed to wait media slot", zap.Error(err))
+		return "", ""
+	}
+	defer release()
+
 	tmpFile, err := os.CreateTemp("", "file-*")
 	if err != nil {
-		panic(err)
+		zap.L().Error("failed to create media temp file", zap.Error(err))
+		return "", ""
 	}
 	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
 
 	// 1. Download do arquivo
-	if err := client.DownloadToFile(ctx, fileMessage, tmpFile); err != nil {
+	if err := client.DownloadToFile(downloadCtx, fileMessage, tmpFile); err != nil {
 		zap.L().Error("failed to download media", zap.Error(err))
 		return "", ""
 	}
@@ -770,34 +1403,32 @@ func (s *Whatsmiau) uploadMessageFile(ctx context.Context, instance *models.Inst
 	// 3. Detecta extensão (pode consumir parte do cursor via sniffing)
 	ext = extractExtFromFile(fileName, mimetype, tmpFile)
 
-	// 4. Seek novamente antes de ler para Base64
-	if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
-		zap.L().Error("failed to seek media file before base64", zap.Error(err))
-		return "", ""
-	}
-
-	// 5. Gera Base64 se habilitado na instância
-	if instance.Webhook.Base64 != nil && *instance.Webhook.Base64 {
-		data, err := io.ReadAll(tmpFile)
-		if err != nil {
+	// 4. Gera Base64 se habilitado na instância, codificando direto do arquivo
+	if withBase64 {
+		if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+			zap.L().Error("failed to seek media file before base64", zap.Error(err))
+		} else if b64Result, err = encodeBase64(tmpFile, env.Env.MediaBase64MaxSize); errors.Is(err, errBase64TooLarge) {
+			zap.L().Warn("media exceeds the base64 max size, skipping base64", zap.String("instance", instance.ID))
+		} else if err != nil {
 			zap.L().Error("failed to read media for base64", zap.Error(err))
 		} else {
-			b64Result = base64.StdEncoding.EncodeToString(data)
 			zap.L().Debug("base64 generated",
 				zap.String("mimetype", mimetype),
 				zap.String("ext", ext),
-				zap.Int("bytes", len(data)),
 				zap.Int("b64Len", len(b64Result)),
 			)
 		}
 	}
 
-	// 6. Upload para storage (GCS etc) se configurado
+	// 5. Upload para storage (GCS etc) se configurado
 	if s.fileStorage != nil {
+		uploadCtx, cancel := context.WithTimeout(context.Background(), env.Env.MediaUploadTimeout)
+		defer cancel()
+
 		if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
 			zap.L().Error("failed to seek media file before upload", zap.Error(err))
 		} else {
-			urlResult, _, err = s.fileStorage.Upload(ctx, uuid.NewString()+"."+ext, mimetype, tmpFile)
+			urlResult, _, err = s.fileStorage.Upload(uploadCtx, uuid.NewString()+"."+ext, mimetype, tmpFile)
 			if err != nil {
 				zap.L().Error("failed to upload media to storage", zap.Error(err))
 			}
@@ -807,6 +1438,35 @@ func (s *Whatsmiau) uploadMessageFile(ctx context.Context, instance *models.Inst
 	return urlResult, b64Result
 }
 
+var errBase64TooLarge = errors.New("media exceeds the base64 max size")
+
+// encodeBase64 encodes the file without keeping its raw content in memory, files bigger than maxSize are
+// refused since the result is kept in memory
+func encodeBase64(file *os.File, maxSize int64) (string, error) {
+	stat, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	if stat.Size() > maxSize {
+		return "", errBase64TooLarge
+	}
+
+	var result strings.Builder
+	result.Grow(base64.StdEncoding.EncodedLen(int(stat.Size())))
+
+	encoder := base64.NewEncoder(base64.StdEncoding, &result)
+	if _, err := io.Copy(encoder, file); err != nil {
+		return "", err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+
+	return result.String(), nil
+}
+
 func (s *Whatsmiau) convertContact(id string, evt *events.Contact) *WookContact {
 	url, _, err := s.getPic(id, evt.JID)
 	if err != nil {
@@ -863,6 +1523,123 @@ func (s *Whatsmiau) convertGroupInfo(id string, evt *events.GroupInfo) *WookCont
 	}
 }
 
+// convertGroupUpdate returns the changed group settings, nil when only the participants changed
+func (s *Whatsmiau) convertGroupUpdate(id string, evt *events.GroupInfo) *WookGroupUpdate {
+	author, authorLid := s.groupAuthor(id, evt.Sender, evt.SenderPN)
+	data := &WookGroupUpdate{
+		ID:         evt.JID.String(),
+		Author:     author,
+		AuthorLid:  authorLid,
+		Timestamp:  evt.Timestamp.Unix(),
+		InstanceId: id,
+	}
+
+	changed := false
+	if evt.Name != nil {
+		data.Subject = &evt.Name.Name
+		changed = true
+	}
+	if evt.Topic != nil {
+		data.Desc = &evt.Topic.Topic
+		changed = true
+	}
+	if evt.Announce != nil {
+		data.Announce = &evt.Announce.IsAnnounce
+		changed = true
+	}
+	if evt.Locked != nil {
+		data.Restrict = &evt.Locked.IsLocked
+		changed = true
+	}
+	if evt.Ephemeral != nil {
+		var duration uint32
+		if evt.Ephemeral.IsEphemeral {
+			duration = evt.Ephemeral.DisappearingTimer
+		}
+		data.EphemeralDuration = &duration
+		changed = true
+	}
+	if evt.MembershipApprovalMode != nil {
+		data.JoinApprovalMode = &evt.MembershipApprovalMode.IsJoinApprovalRequired
+		changed = true
+	}
+	if evt.NewInviteLink != nil {
+		code := strings.TrimPrefix(*evt.NewInviteLink, whatsmeow.InviteLinkPrefix)
+		data.InviteCode = &code
+		changed = true
+	}
+	if evt.Delete != nil {
+		data.Deleted = evt.Delete.Deleted
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return data
+}
+
+// convertGroupParticipantsUpdate returns an update for each action (add, remove, promote and demote) of the event
+func (s *Whatsmiau) convertGroupParticipantsUpdate(id string, evt *events.GroupInfo) []WookGroupParticipantsUpdateData {
+	changes := []struct {
+		action WookGroupParticipantsAction
+		jids   []types.JID
+	}{
+		{WookGroupParticipantsAdd, evt.Join},
+		{WookGroupParticipantsRemove, evt.Leave},
+		{WookGroupParticipantsPromote, evt.Promote},
+		{WookGroupParticipantsDemote, evt.Demote},
+	}
+
+	var result []WookGroupParticipantsUpdateData
+	for _, change := range changes {
+		if len(change.jids) == 0 {
+			continue
+		}
+
+		author, authorLid := s.groupAuthor(id, evt.Sender, evt.SenderPN)
+		data := WookGroupParticipantsUpdateData{
+			ID:           evt.JID.String(),
+			Author:       author,
+			AuthorLid:    authorLid,
+			Participants: make([]GroupParticipant, 0, len(change.jids)),
+			Action:       change.action,
+			Timestamp:    evt.Timestamp.Unix(),
+			InstanceId:   id,
+		}
+		if change.action == WookGroupParticipantsAdd {
+			data.Reason = evt.JoinReason
+		}
+
+		for _, jid := range change.jids {
+			participantJid, participantLid := s.GetJidLid(context.Background(), id, jid)
+			data.Participants = append(data.Participants, GroupParticipant{
+				ID:  participantJid,
+				Lid: participantLid,
+			})
+		}
+
+		result = append(result, data)
+	}
+
+	return result
+}
+
+// groupAuthor returns the jid and lid of who changed the group, the sender is missing for some changes
+func (s *Whatsmiau) groupAuthor(id string, sender, senderPN *types.JID) (string, string) {
+	if sender == nil || sender.IsEmpty() {
+		return "", ""
+	}
+
+	jid, lid := s.GetJidLid(context.Background(), id, *sender)
+	if senderPN != nil && !senderPN.IsEmpty() {
+		jid = senderPN.ToNonAD().String()
+	}
+
+	return jid, lid
+}
+
 func (s *Whatsmiau) convertPushName(id string, evt *events.PushName) *WookContact {
 	url, _, err := s.getPic(id, evt.JID)
 	if err != nil {
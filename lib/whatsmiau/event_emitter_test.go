@@ -0,0 +1,148 @@
+package whatsmiau
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestWebhookUrl(t *testing.T) {
+	yes, no := true, false
+
+	tests := []struct {
+		name    string
+		webhook models.InstanceWebhook
+		want    string
+	}{
+		{name: "byEvents unset", webhook: models.InstanceWebhook{Url: "https://example.com/hook"}, want: "https://example.com/hook"},
+		{name: "byEvents disabled", webhook: models.InstanceWebhook{Url: "https://example.com/hook", ByEvents: &no}, want: "https://example.com/hook"},
+		{name: "byEvents enabled", webhook: models.InstanceWebhook{Url: "https://example.com/hook", ByEvents: &yes}, want: "https://example.com/hook/messages-upsert"},
+		{name: "trailing slash", webhook: models.InstanceWebhook{Url: "https://example.com/hook/", ByEvents: &yes}, want: "https://example.com/hook/messages-upsert"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := webhookUrl(&tt.webhook, WookMessagesUpsert); got != tt.want {
+				t.Errorf("webhookUrl() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeBase64(t *testing.T) {
+	tests := []struct {
+		content string
+		maxSize int64
+		want    string
+		err     error
+	}{
+		{content: "hello", maxSize: 5, want: "aGVsbG8="},
+		{content: "", maxSize: 0, want: ""},
+		{content: "hello!", maxSize: 5, err: errBase64TooLarge},
+	}
+
+	for _, tt := range tests {
+		file, err := os.CreateTemp(t.TempDir(), "media-*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.WriteString(tt.content); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Seek(0, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := encodeBase64(file, tt.maxSize)
+		if !errors.Is(err, tt.err) || got != tt.want {
+			t.Errorf("encodeBase64(%q, %d) = %q, %v, want %q, %v", tt.content, tt.maxSize, got, err, tt.want, tt.err)
+		}
+		_ = file.Close()
+	}
+}
+
+func TestIsContentMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *waE2E.Message
+		want bool
+	}{
+		{name: "nil", msg: nil, want: false},
+		{name: "empty", msg: &waE2E.Message{}, want: false},
+		{name: "text", msg: &waE2E.Message{Conversation: proto.String("hi")}, want: true},
+		{name: "image", msg: &waE2E.Message{ImageMessage: &waE2E.ImageMessage{}}, want: true},
+		{name: "group text with sender key", msg: &waE2E.Message{
+			SenderKeyDistributionMessage: &waE2E.SenderKeyDistributionMessage{},
+			ExtendedTextMessage:          &waE2E.ExtendedTextMessage{Text: proto.String("hi")},
+		}, want: true},
+		{name: "sender key only", msg: &waE2E.Message{SenderKeyDistributionMessage: &waE2E.SenderKeyDistributionMessage{}}, want: false},
+		{name: "edit", msg: &waE2E.Message{ProtocolMessage: &waE2E.ProtocolMessage{Type: waE2E.ProtocolMessage_MESSAGE_EDIT.Enum()}}, want: false},
+		{name: "reaction", msg: &waE2E.Message{ReactionMessage: &waE2E.ReactionMessage{Text: proto.String("👍")}}, want: false},
+		{name: "poll vote", msg: &waE2E.Message{PollUpdateMessage: &waE2E.PollUpdateMessage{}}, want: false},
+		{name: "pin", msg: &waE2E.Message{PinInChatMessage: &waE2E.PinInChatMessage{}}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isContentMessage(tt.msg); got != tt.want {
+			t.Errorf("isContentMessage(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestConvertMediaWithoutWebhook covers instances only publishing to brokers or streams
+func TestConvertMediaWithoutWebhook(t *testing.T) {
+	env.Env.MediaMaxSize = 1 << 20
+	env.Env.MediaBase64MaxSize = 1 << 20
+
+	container, err := sqlstore.New(context.Background(), "sqlite3", "file:"+t.TempDir()+"/store.db?_foreign_keys=on", nil)
+	if err != nil {
+		t.Fatalf("failed to open the store: %v", err)
+	}
+
+	device := container.NewDevice()
+	device.LIDs = container.LIDMap // set when the device is paired
+
+	s := &Whatsmiau{clients: xsync.NewMap[string, *whatsmeow.Client]()}
+	s.clients.Store("a", whatsmeow.NewClient(device, nil))
+
+	chat := types.NewJID("5511999999999", types.DefaultUserServer)
+	messages := map[string]*waE2E.Message{
+		"imageMessage":    {ImageMessage: &waE2E.ImageMessage{Mimetype: proto.String("image/jpeg"), FileLength: proto.Uint64(10)}},
+		"videoMessage":    {VideoMessage: &waE2E.VideoMessage{Mimetype: proto.String("video/mp4"), FileLength: proto.Uint64(10)}},
+		"documentMessage": {DocumentMessage: &waE2E.DocumentMessage{Mimetype: proto.String("application/pdf"), FileName: proto.String("a.pdf")}},
+		"audioMessage":    {AudioMessage: &waE2E.AudioMessage{Mimetype: proto.String("audio/ogg")}},
+	}
+
+	for messageType, message := range messages {
+		evt := &events.Message{
+			Info: types.MessageInfo{
+				MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+				ID:            "ID-" + messageType,
+				Timestamp:     time.Now(),
+			},
+			Message:    message,
+			RawMessage: message,
+		}
+
+		data := s.convertEventMessage("a", &models.Instance{ID: "a"}, evt, true)
+		if data == nil || data.MessageType != messageType {
+			t.Fatalf("convertEventMessage() of %s = %+v", messageType, data)
+		}
+		if data.Message.Base64 != "" || data.Message.MediaURL != "" {
+			t.Errorf("%s without webhook nor storage has media: %+v", messageType, data.Message)
+		}
+	}
+}
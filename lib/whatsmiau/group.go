@@ -0,0 +1,362 @@
+package whatsmiau
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+var ErrInvalidGroupSetting = errors.New("invalid group setting, use announcement, not_announcement, locked or unlocked")
+
+// Group follows the group metadata returned by the Evolution API
+type Group struct {
+	ID           string             `json:"id"`
+	Subject      string             `json:"subject"`
+	SubjectOwner string             `json:"subjectOwner,omitempty"`
+	SubjectTime  int64              `json:"subjectTime,omitempty"`
+	PictureUrl   string             `json:"pictureUrl,omitempty"`
+	Size         int                `json:"size"`
+	Creation     int64              `json:"creation"`
+	Owner        string             `json:"owner,omitempty"`
+	Desc         string             `json:"desc,omitempty"`
+	DescId       string             `json:"descId,omitempty"`
+	Restrict     bool               `json:"restrict"` // only admins edit the group info (locked)
+	Announce     bool               `json:"announce"` // only admins send messages
+	IsCommunity  bool               `json:"isCommunity"`
+	Participants []GroupParticipant `json:"participants,omitempty"`
+}
+
+type GroupParticipant struct {
+	ID    string  `json:"id"`
+	Lid   string  `json:"lid,omitempty"`
+	Admin *string `json:"admin"` // superadmin, admin or null
+}
+
+func convertGroupParticipant(participant types.GroupParticipant) GroupParticipant {
+	result := GroupParticipant{ID: participant.JID.String()}
+	if !participant.PhoneNumber.IsEmpty() {
+		result.ID = participant.PhoneNumber.String()
+	}
+	if !participant.LID.IsEmpty() {
+		result.Lid = participant.LID.String()
+	}
+
+	var admin string
+	switch {
+	case participant.IsSuperAdmin:
+		admin = "superadmin"
+	case participant.IsAdmin:
+		admin = "admin"
+	}
+	if admin != "" {
+		result.Admin = &admin
+	}
+
+	return result
+}
+
+func convertGroup(info *types.GroupInfo, withParticipants bool) Group {
+	group := Group{
+		ID:          info.JID.String(),
+		Subject:     info.Name,
+		Size:        len(info.Participants),
+		Creation:    info.GroupCreated.Unix(),
+		Desc:        info.Topic,
+		DescId:      info.TopicID,
+		Restrict:    info.IsLocked,
+		Announce:    info.IsAnnounce,
+		IsCommunity: info.IsParent,
+	}
+
+	if owner := info.OwnerPN; !owner.IsEmpty() {
+		group.Owner = owner.String()
+	} else if !info.OwnerJID.IsEmpty() {
+		group.Owner = info.OwnerJID.String()
+	}
+
+	if setBy := info.NameSetByPN; !setBy.IsEmpty() {
+		group.SubjectOwner = setBy.String()
+	} else if !info.NameSetBy.IsEmpty() {
+		group.SubjectOwner = info.NameSetBy.String()
+	}
+	if !info.NameSetAt.IsZero() {
+		group.SubjectTime = info.NameSetAt.Unix()
+	}
+
+	if withParticipants {
+		group.Participants = make([]GroupParticipant, 0, len(info.Participants))
+		for _, participant := range info.Participants {
+			group.Participants = append(group.Participants, convertGroupParticipant(participant))
+		}
+	}
+
+	return group
+}
+
+type CreateGroupRequest struct {
+	InstanceID   string      `json:"instance_id"`
+	Subject      string      `json:"subject"`
+	Description  string      `json:"description"`
+	Participants []types.JID `json:"participants"`
+}
+
+func (s *Whatsmiau) CreateGroup(ctx context.Context, data *CreateGroupRequest) (*Group, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	info, err := client.CreateGroup(ctx, whatsmeow.ReqCreateGroup{
+		Name:         data.Subject,
+		Participants: data.Participants,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if data.Description != "" {
+		if err := client.SetGroupTopic(ctx, info.JID, info.TopicID, "", data.Description); err != nil {
+			zap.L().Warn("failed to set the created group description", zap.String("group", info.JID.String()), zap.Error(err))
+		} else {
+			info.Topic = data.Description
+		}
+	}
+
+	group := convertGroup(info, true)
+	return &group, nil
+}
+
+func (s *Whatsmiau) FetchAllGroups(ctx context.Context, instanceID string, withParticipants bool) ([]Group, error) {
+	client, ok := s.clients.Load(instanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	infos, err := client.GetJoinedGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]Group, 0, len(infos))
+	for _, info := range infos {
+		groups = append(groups, convertGroup(info, withParticipants))
+	}
+
+	return groups, nil
+}
+
+// GroupInfo returns the group with its participants and picture
+func (s *Whatsmiau) GroupInfo(ctx context.Context, instanceID string, groupJID types.JID) (*Group, error) {
+	client, ok := s.clients.Load(instanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	info, err := client.GetGroupInfo(ctx, groupJID)
+	if err != nil {
+		return nil, err
+	}
+
+	group := convertGroup(info, true)
+	picture, err := client.GetProfilePictureInfo(ctx, groupJID, &whatsmeow.GetProfilePictureParams{})
+	if err != nil {
+		zap.L().Debug("failed to get group picture", zap.String("group", groupJID.String()), zap.Error(err))
+	} else if picture != nil {
+		group.PictureUrl = picture.URL
+	}
+
+	return &group, nil
+}
+
+func (s *Whatsmiau) GroupParticipants(ctx context.Context, instanceID string, groupJID types.JID) ([]GroupParticipant, error) {
+	client, ok := s.clients.Load(instanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	info, err := client.GetGroupInfo(ctx, groupJID)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertGroup(info, true).Participants, nil
+}
+
+func (s *Whatsmiau) UpdateGroupSubject(ctx context.Context, instanceID string, groupJID types.JID, subject string) error {
+	client, ok := s.clients.Load(instanceID)
+	if !ok {
+		return whatsmeow.ErrClientIsNil
+	}
+
+	return client.SetGroupName(ctx, groupJID, subject)
+}
+
+func (s *Whatsmiau) UpdateGroupDescription(ctx context.Context, instanceID string, groupJID types.JID, description string) error {
+	client, ok := s.clients.Load(instanceID)
+	if !ok {
+		return whatsmeow.ErrClientIsNil
+	}
+
+	// an empty topic deletes the description
+	return client.SetGroupTopic(ctx, groupJID, "", "", description)
+}
+
+type UpdateGroupPictureRequest struct {
+	InstanceID string     `json:"instance_id"`
+	GroupJID   *types.JID `json:"group_jid"`
+	Image      string     `json:"image"` // url, base64 or data URI
+}
+
+// UpdateGroupPicture converts the image to a square jpeg, the only format accepted by WhatsApp
+func (s *Whatsmiau) UpdateGroupPicture(ctx context.Context, data *UpdateGroupPictureRequest) (string, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return "", whatsmeow.ErrClientIsNil
+	}
+
+	release, err := s.acquireMedia(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	file, err := s.openMedia(ctx, data.Image, nil)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if !strings.HasPrefix(file.sniffed, "image/") {
+		return "", fmt.Errorf("%w: group picture must be an image", ErrInvalidMedia)
+	}
+
+	raw, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+
+	picture, err := convertGroupPicture(ctx, raw)
+	if err != nil {
+		if file.sniffed != "image/jpeg" {
+			return "", err
+		}
+		zap.L().Warn("failed to convert group picture, sending the original jpeg", zap.Error(err))
+		picture = raw
+	}
+
+	return client.SetGroupPhoto(ctx, *data.GroupJID, picture)
+}
+
+type UpdateGroupParticipantsRequest struct {
+	InstanceID   string                      `json:"instance_id"`
+	GroupJID     *types.JID                  `json:"group_jid"`
+	Action       whatsmeow.ParticipantChange `json:"action"` // add, remove, promote or demote
+	Participants []types.JID                 `json:"participants"`
+}
+
+type UpdateGroupParticipantResult struct {
+	Status string `json:"status"` // 200 or the WhatsApp error code
+	Jid    string `json:"jid"`
+}
+
+func (s *Whatsmiau) UpdateGroupParticipants(ctx context.Context, data *UpdateGroupParticipantsRequest) ([]UpdateGroupParticipantResult, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	participants, err := client.UpdateGroupParticipants(ctx, *data.GroupJID, data.Participants, data.Action)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]UpdateGroupParticipantResult, 0, len(participants))
+	for _, participant := range participants {
+		status := "200"
+		if participant.Error != 0 {
+			status = strconv.Itoa(participant.Error)
+		}
+
+		results = append(results, UpdateGroupParticipantResult{
+			Status: status,
+			Jid:    convertGroupParticipant(participant).ID,
+		})
+	}
+
+	return results, nil
+}
+
+// UpdateGroupSetting applies the Evolution setting actions: announcement, not_announcement, locked and unlocked
+func (s *Whatsmiau) UpdateGroupSetting(ctx context.Context, instanceID string, groupJID types.JID, action string) error {
+	client, ok := s.clients.Load(instanceID)
+	if !ok {
+		return whatsmeow.ErrClientIsNil
+	}
+
+	switch action {
+	case "announcement", "not_announcement":
+		return client.SetGroupAnnounce(ctx, groupJID, action == "announcement")
+	case "locked", "unlocked":
+		return client.SetGroupLocked(ctx, groupJID, action == "locked")
+	default:
+		return ErrInvalidGroupSetting
+	}
+}
+
+// GroupInviteCode returns the invite code of the group, reset revokes the current one and returns the new
+func (s *Whatsmiau) GroupInviteCode(ctx context.Context, instanceID string, groupJID types.JID, reset bool) (string, error) {
+	client, ok := s.clients.Load(instanceID)
+	if !ok {
+		return "", whatsmeow.ErrClientIsNil
+	}
+
+	link, err := client.GetGroupInviteLink(ctx, groupJID, reset)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimPrefix(link, whatsmeow.InviteLinkPrefix), nil
+}
+
+// GroupInviteInfo returns the group of the invite code (or link) without joining it
+func (s *Whatsmiau) GroupInviteInfo(ctx context.Context, instanceID, code string) (*Group, error) {
+	client, ok := s.clients.Load(instanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	info, err := client.GetGroupInfoFromLink(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	group := convertGroup(info, true)
+	return &group, nil
+}
+
+// JoinGroup accepts the invite code (or link), the returned jid is the group or the membership request
+// when the group requires approval
+func (s *Whatsmiau) JoinGroup(ctx context.Context, instanceID, code string) (types.JID, error) {
+	client, ok := s.clients.Load(instanceID)
+	if !ok {
+		return types.EmptyJID, whatsmeow.ErrClientIsNil
+	}
+
+	return client.JoinGroupWithLink(ctx, code)
+}
+
+func (s *Whatsmiau) LeaveGroup(ctx context.Context, instanceID string, groupJID types.JID) error {
+	client, ok := s.clients.Load(instanceID)
+	if !ok {
+		return whatsmeow.ErrClientIsNil
+	}
+
+	return client.LeaveGroup(ctx, groupJID)
+}
@@ -20,9 +20,13 @@ import (
 	"github.com/verbeux-ai/whatsmiau/env"
 	"github.com/verbeux-ai/whatsmiau/models"
 	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waCompanionReg"
+	"go.mau.fi/whatsmeow/proto/waWa6"
+	"go.mau.fi/whatsmeow/store"
 	"go.mau.fi/whatsmeow/types/events"
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
 )
 
 type ProxyInfo struct {
@@ -48,13 +52,19 @@ func i64(n int64) string {
 	return strconv.FormatInt(n, 10)
 }
 
+// intPtr is the missing proto.Int, protobuf only has the sized ones
+func intPtr(n int) *int {
+	return &n
+}
+
+// getCtx downloads with the media client, which has no timeout of its own, the ctx must bound it
 func (s *Whatsmiau) getCtx(ctx context.Context, url string) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := s.httpClient.Do(req)
+	res, err := s.mediaClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -62,27 +72,16 @@ func (s *Whatsmiau) getCtx(ctx context.Context, url string) (*http.Response, err
 	return res, nil
 }
 
-// Returns audioConverted, waveform, duration and an error
-func convertAudio(data []byte, bars int) ([]byte, []byte, float64, error) {
+// Returns audioConverted, waveform, duration and an error, reading the audio from path
+func convertAudio(ctx context.Context, path string, bars int) ([]byte, []byte, float64, error) {
 	if _, err := exec.LookPath("ffmpeg"); err != nil {
 		return nil, nil, 0, errors.New("ffmpeg not found in path (install to decode .ogg opus/vorbis)")
 	}
 
-	tempIn, err := os.CreateTemp("", "audio-*.ogg")
-	if err != nil {
-		return nil, nil, 0, err
-	}
-	defer os.Remove(tempIn.Name())
-	if _, err := io.Copy(tempIn, bytes.NewReader(data)); err != nil {
-		return nil, nil, 0, err
-	}
-	if err := tempIn.Close(); err != nil {
-		return nil, nil, 0, err
-	}
-
-	out, err := exec.Command(
+	out, err := exec.CommandContext(
+		ctx,
 		"ffmpeg",
-		"-i", tempIn.Name(),
+		"-i", path,
 		"-ac", "1",
 		"-ar", "48000",
 		"-f", "s16le",
@@ -98,9 +97,10 @@ func convertAudio(data []byte, bars int) ([]byte, []byte, float64, error) {
 	}
 
 	// Also convert to Ogg/Opus for stable playback/sharing
-	oggOut, err := exec.Command(
+	oggOut, err := exec.CommandContext(
+		ctx,
 		"ffmpeg",
-		"-i", tempIn.Name(),
+		"-i", path,
 		"-vn",
 		"-c:a", "libopus",
 		"-b:a", "64k",
@@ -154,6 +154,201 @@ func convertAudio(data []byte, bars int) ([]byte, []byte, float64, error) {
 	return oggOut, buf, durationSec, nil
 }
 
+type videoInfo struct {
+	Seconds   uint32
+	Width     uint32
+	Height    uint32
+	Thumbnail []byte // jpeg
+}
+
+// convertVideo returns the path of the video as mp4 (h264/aac) with its duration, size and a jpeg thumbnail
+// of the first second. Other formats (ex: gif, webm) are transcoded to a new temp file, removed by the caller
+// when it differs from path
+func convertVideo(ctx context.Context, path, mimetype string) (string, *videoInfo, error) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return "", nil, errors.New("ffmpeg not found in path (install to send videos)")
+	}
+
+	videoPath := path
+	if mimetype != "video/mp4" {
+		tempOut, err := os.CreateTemp("", "video-*.mp4")
+		if err != nil {
+			return "", nil, err
+		}
+		_ = tempOut.Close()
+
+		if out, err := exec.CommandContext(
+			ctx,
+			"ffmpeg",
+			"-y",
+			"-i", path,
+			"-c:v", "libx264",
+			"-pix_fmt", "yuv420p",
+			"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2",
+			"-c:a", "aac",
+			"-movflags", "+faststart",
+			"-hide_banner",
+			"-loglevel", "error",
+			tempOut.Name(),
+		).CombinedOutput(); err != nil {
+			_ = os.Remove(tempOut.Name())
+			return "", nil, fmt.Errorf("failed converting to mp4: %w: %s", err, string(out))
+		}
+
+		videoPath = tempOut.Name()
+	}
+
+	info := &videoInfo{}
+	probe, err := exec.CommandContext(
+		ctx,
+		"ffprobe",
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=width,height:format=duration",
+		"-of", "default=noprint_wrappers=1",
+		videoPath,
+	).Output()
+	if err != nil {
+		zap.L().Warn("failed to probe video", zap.Error(err))
+	}
+	for _, line := range strings.Split(string(probe), "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), "=")
+		switch key {
+		case "width":
+			n, _ := strconv.ParseUint(value, 10, 32)
+			info.Width = uint32(n)
+		case "height":
+			n, _ := strconv.ParseUint(value, 10, 32)
+			info.Height = uint32(n)
+		case "duration":
+			secs, _ := strconv.ParseFloat(value, 64)
+			info.Seconds = uint32(math.Round(secs))
+		}
+	}
+
+	thumbnail, err := exec.CommandContext(
+		ctx,
+		"ffmpeg",
+		"-ss", "00:00:01",
+		"-i", videoPath,
+		"-frames:v", "1",
+		"-vf", "scale=320:-2",
+		"-f", "mjpeg",
+		"-hide_banner",
+		"-loglevel", "error",
+		"pipe:1",
+	).Output()
+	if err != nil || len(thumbnail) == 0 {
+		// videos shorter than 1s, take the first frame
+		thumbnail, err = exec.CommandContext(
+			ctx,
+			"ffmpeg",
+			"-i", videoPath,
+			"-frames:v", "1",
+			"-vf", "scale=320:-2",
+			"-f", "mjpeg",
+			"-hide_banner",
+			"-loglevel", "error",
+			"pipe:1",
+		).Output()
+	}
+	if err != nil {
+		zap.L().Warn("failed to generate video thumbnail", zap.Error(err))
+	}
+	info.Thumbnail = thumbnail
+
+	return videoPath, info, nil
+}
+
+// convertSticker returns the image of path as a 512x512 webp, keeping the aspect ratio with a transparent padding
+func convertSticker(ctx context.Context, path string) ([]byte, error) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return nil, errors.New("ffmpeg not found in path (install to send stickers)")
+	}
+
+	out, err := exec.CommandContext(
+		ctx,
+		"ffmpeg",
+		"-i", path,
+		"-frames:v", "1",
+		"-vf", "scale=512:512:force_original_aspect_ratio=decrease,format=rgba,pad=512:512:(ow-iw)/2:(oh-ih)/2:color=0x00000000",
+		"-c:v", "libwebp",
+		"-quality", "80",
+		"-f", "webp",
+		"-hide_banner",
+		"-loglevel", "error",
+		"pipe:1",
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed converting to webp: %w", err)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no data after webp conversion")
+	}
+
+	return out, nil
+}
+
+// convertThumbnail returns the image as a jpeg of up to 300px wide, used in link previews
+func convertThumbnail(ctx context.Context, data []byte) ([]byte, error) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return nil, errors.New("ffmpeg not found in path (install to generate thumbnails)")
+	}
+
+	cmd := exec.CommandContext(
+		ctx,
+		"ffmpeg",
+		"-i", "pipe:0",
+		"-frames:v", "1",
+		"-vf", "scale='min(300,iw)':-2",
+		"-f", "mjpeg",
+		"-hide_banner",
+		"-loglevel", "error",
+		"pipe:1",
+	)
+	cmd.Stdin = bytes.NewReader(data)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed converting to jpeg: %w", err)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no data after jpeg conversion")
+	}
+
+	return out, nil
+}
+
+// convertGroupPicture center crops the image to a square jpeg of at most 640px
+func convertGroupPicture(ctx context.Context, data []byte) ([]byte, error) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return nil, errors.New("ffmpeg not found in path (install to convert group pictures)")
+	}
+
+	cmd := exec.CommandContext(
+		ctx,
+		"ffmpeg",
+		"-i", "pipe:0",
+		"-frames:v", "1",
+		"-vf", "crop='min(iw,ih)':'min(iw,ih)',scale='min(640,iw)':-2",
+		"-f", "mjpeg",
+		"-hide_banner",
+		"-loglevel", "error",
+		"pipe:1",
+	)
+	cmd.Stdin = bytes.NewReader(data)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed converting group picture to jpeg: %w", ErrInvalidMedia, err)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no data after jpeg conversion")
+	}
+
+	return out, nil
+}
+
 func rmsByBars(samples []int16, bars int) []float64 {
 	if bars < 1 {
 		bars = 1
@@ -212,25 +407,6 @@ func percentile(xs []float64, p float64) float64 {
 	return cp[idx]
 }
 
-func extractMimetype(decodedData []byte, fileName string) (string, error) {
-	ext := filepath.Ext(fileName)
-	if ext != "" {
-		mimeType := mime.TypeByExtension(ext)
-		if mimeType != "" {
-			return mimeType, nil
-		}
-	}
-
-	var dataSample []byte
-	if len(decodedData) > 512 {
-		dataSample = decodedData[:512]
-	} else {
-		dataSample = decodedData
-	}
-	detected := http.DetectContentType(dataSample)
-	return detected, nil
-}
-
 func extractExtFromFile(fileName, mimeType string, file *os.File) string {
 	ext := filepath.Ext(fileName)
 	if ext == "" {
@@ -339,6 +515,38 @@ func configProxy(client *whatsmeow.Client, proxy *ProxyInfo) {
 	}
 }
 
+// fullHistorySyncDays is how many days of history are requested when SyncFullHistory is set
+const fullHistorySyncDays = 3650
+
+// configHistorySync asks WhatsApp for the full history (instead of the recent messages only) when the
+// device is paired, it has no effect on devices already paired
+func configHistorySync(client *whatsmeow.Client, full bool) {
+	if !full {
+		client.GetClientPayload = nil
+		return
+	}
+
+	client.GetClientPayload = func() *waWa6.ClientPayload {
+		payload := client.Store.GetClientPayload()
+		if payload.DevicePairingData == nil {
+			return payload
+		}
+
+		props := proto.Clone(store.DeviceProps).(*waCompanionReg.DeviceProps)
+		props.RequireFullSync = proto.Bool(true)
+		props.HistorySyncConfig.FullSyncDaysLimit = proto.Uint32(fullHistorySyncDays)
+
+		data, err := proto.Marshal(props)
+		if err != nil {
+			zap.L().Error("failed to marshal device props", zap.Error(err))
+			return payload
+		}
+
+		payload.DevicePairingData.DeviceProps = data
+		return payload
+	}
+}
+
 func mountProxyUrl(proxy *ProxyInfo) string {
 	return fmt.Sprintf("%s://%s:%s@%s:%d", proxy.Protocol, proxy.Username, proxy.Password, proxy.Host, proxy.Port)
 }
@@ -0,0 +1,162 @@
+package whatsmiau
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow"
+	"golang.org/x/net/context"
+)
+
+var (
+	ErrMediaTooLarge = errors.New("media exceeds the max size")
+	ErrInvalidMedia  = errors.New("invalid media")
+)
+
+// mediaFile is a media kept in a temp file so it is never fully loaded in memory, Close removes it
+type mediaFile struct {
+	*os.File
+	Size     int64
+	declared string // from the data URI
+	sniffed  string
+}
+
+func (f *mediaFile) Close() error {
+	_ = f.File.Close()
+	return os.Remove(f.Name())
+}
+
+// mimetype returns the declared mimetype, then the one of the fileName extension and at last the sniffed one
+func (f *mediaFile) mimetype(fileName string) string {
+	if f.declared != "" {
+		return f.declared
+	}
+
+	if ext := filepath.Ext(fileName); ext != "" {
+		if mimetype := mime.TypeByExtension(ext); mimetype != "" {
+			return mimetype
+		}
+	}
+
+	return f.sniffed
+}
+
+// acquireMedia limits the media being downloaded, converted or uploaded at the same time (MEDIA_CONCURRENCY)
+func (s *Whatsmiau) acquireMedia(ctx context.Context) (func(), error) {
+	select {
+	case s.mediaSemaphore <- struct{}{}:
+		return func() { <-s.mediaSemaphore }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// openMedia writes the uploaded reader or media, which can be an url, raw base64 or a data URI
+// (data:<mimetype>;base64,<data>), to a temp file respecting MEDIA_MAX_SIZE. The caller must Close it
+func (s *Whatsmiau) openMedia(ctx context.Context, media string, reader io.Reader) (*mediaFile, error) {
+	var declared string
+
+	media = strings.TrimSpace(media)
+	switch {
+	case reader != nil:
+	case len(media) == 0:
+		return nil, fmt.Errorf("%w: empty media", ErrInvalidMedia)
+	case strings.HasPrefix(media, "http://") || strings.HasPrefix(media, "https://"):
+		downloadCtx, cancel := context.WithTimeout(ctx, env.Env.MediaDownloadTimeout)
+		defer cancel()
+
+		res, err := s.getCtx(downloadCtx, media)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode >= 400 {
+			return nil, fmt.Errorf("%w: download responded %d", ErrInvalidMedia, res.StatusCode)
+		}
+
+		if res.ContentLength > env.Env.MediaMaxSize {
+			return nil, ErrMediaTooLarge
+		}
+
+		reader = res.Body
+	default:
+		if strings.HasPrefix(media, "data:") {
+			header, payload, ok := strings.Cut(strings.TrimPrefix(media, "data:"), ",")
+			if !ok || !strings.HasSuffix(header, ";base64") {
+				return nil, fmt.Errorf("%w: data uri must be base64 encoded", ErrInvalidMedia)
+			}
+
+			declared, _, _ = strings.Cut(header, ";")
+			media = payload
+		}
+
+		// some clients strip the padding
+		if pad := len(media) % 4; pad > 0 {
+			media += strings.Repeat("=", 4-pad)
+		}
+
+		reader = base64.NewDecoder(base64.StdEncoding, strings.NewReader(media))
+	}
+
+	tmp, err := os.CreateTemp("", "media-*")
+	if err != nil {
+		return nil, err
+	}
+
+	file := &mediaFile{File: tmp, declared: declared}
+	if err := file.fill(reader); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// fill copies the reader to the file, sniffs its content type and rewinds it
+func (f *mediaFile) fill(reader io.Reader) error {
+	size, err := io.Copy(f.File, io.LimitReader(reader, env.Env.MediaMaxSize+1))
+	if err != nil {
+		var corrupt base64.CorruptInputError
+		if errors.As(err, &corrupt) {
+			return fmt.Errorf("%w: media is not an url nor base64", ErrInvalidMedia)
+		}
+		return err
+	}
+
+	if size > env.Env.MediaMaxSize {
+		return ErrMediaTooLarge
+	}
+
+	if size == 0 {
+		return fmt.Errorf("%w: empty media", ErrInvalidMedia)
+	}
+
+	head := make([]byte, 512)
+	n, err := f.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	f.Size = size
+	f.sniffed = http.DetectContentType(head[:n])
+
+	_, err = f.Seek(0, io.SeekStart)
+	return err
+}
+
+// uploadMedia encrypts and uploads the media streaming from the reader, bounded by MEDIA_UPLOAD_TIMEOUT
+func (s *Whatsmiau) uploadMedia(ctx context.Context, client *whatsmeow.Client, reader io.Reader, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	uploadCtx, cancel := context.WithTimeout(ctx, env.Env.MediaUploadTimeout)
+	defer cancel()
+
+	return client.UploadReader(uploadCtx, reader, nil, mediaType)
+}
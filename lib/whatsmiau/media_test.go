@@ -0,0 +1,70 @@
+package whatsmiau
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"golang.org/x/net/context"
+)
+
+func TestOpenMedia(t *testing.T) {
+	env.Env.MediaMaxSize = 16
+
+	pdf := "%PDF-1.4 hello"
+	encoded := base64.StdEncoding.EncodeToString([]byte(pdf))
+	unpadded := base64.RawStdEncoding.EncodeToString([]byte("hello"))
+
+	tests := []struct {
+		name     string
+		media    string
+		reader   io.Reader
+		want     string
+		mimetype string
+		err      error
+	}{
+		{name: "raw base64", media: encoded, want: pdf, mimetype: "application/pdf"},
+		{name: "data uri", media: "data:image/png;base64," + encoded, want: pdf, mimetype: "image/png"},
+		{name: "surrounding spaces", media: "  " + encoded + "\n", want: pdf, mimetype: "application/pdf"},
+		{name: "missing padding", media: unpadded, want: "hello", mimetype: "text/plain; charset=utf-8"},
+		{name: "reader", reader: strings.NewReader("hello"), want: "hello", mimetype: "text/plain; charset=utf-8"},
+		{name: "exactly the max size", media: base64.StdEncoding.EncodeToString(make([]byte, 16)), want: string(make([]byte, 16)), mimetype: "application/octet-stream"},
+		{name: "over the max size", media: base64.StdEncoding.EncodeToString(make([]byte, 17)), err: ErrMediaTooLarge},
+		{name: "reader over the max size", reader: strings.NewReader(strings.Repeat("a", 17)), err: ErrMediaTooLarge},
+		{name: "empty", media: " ", err: ErrInvalidMedia},
+		{name: "empty reader", reader: strings.NewReader(""), err: ErrInvalidMedia},
+		{name: "not base64", media: "not base64!", err: ErrInvalidMedia},
+		{name: "data uri without base64", media: "data:text/plain,hello", err: ErrInvalidMedia},
+	}
+
+	s := &Whatsmiau{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := s.openMedia(context.Background(), tt.media, tt.reader)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("openMedia() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("openMedia() error: %v", err)
+			}
+			defer file.Close()
+
+			data, err := io.ReadAll(file)
+			if err != nil {
+				t.Fatalf("failed to read media: %v", err)
+			}
+			if string(data) != tt.want || file.Size != int64(len(tt.want)) {
+				t.Errorf("media = %q (%d bytes), want %q", data, file.Size, tt.want)
+			}
+			if got := file.mimetype(""); got != tt.mimetype {
+				t.Errorf("mimetype = %s, want %s", got, tt.mimetype)
+			}
+		})
+	}
+}
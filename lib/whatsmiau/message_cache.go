@@ -0,0 +1,129 @@
+package whatsmiau
+
+import (
+	"sync"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"golang.org/x/net/context"
+)
+
+// cachedMessage is a sent or received message kept to be forwarded later
+type cachedMessage struct {
+	ID        string
+	Chat      types.JID
+	Sender    types.JID
+	FromMe    bool
+	Timestamp time.Time
+	Message   *waE2E.Message
+}
+
+// messageCache keeps the last MESSAGE_CACHE_SIZE messages of an instance, the oldest are dropped first
+type messageCache struct {
+	mu       sync.Mutex
+	order    []string // ring of message ids
+	next     int
+	messages map[string]*cachedMessage
+}
+
+func (c *messageCache) add(msg *cachedMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.messages[msg.ID]; ok {
+		c.messages[msg.ID] = msg
+		return
+	}
+
+	if len(c.order) < cap(c.order) {
+		c.order = append(c.order, msg.ID)
+	} else {
+		delete(c.messages, c.order[c.next])
+		c.order[c.next] = msg.ID
+		c.next = (c.next + 1) % len(c.order)
+	}
+
+	c.messages[msg.ID] = msg
+}
+
+func (c *messageCache) get(id string) (*cachedMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	msg, ok := c.messages[id]
+	return msg, ok
+}
+
+// canCacheMessage returns false for messages that only change other messages (reactions, votes, edits...)
+func canCacheMessage(msg *waE2E.Message) bool {
+	return msg != nil &&
+		msg.ProtocolMessage == nil &&
+		msg.ReactionMessage == nil &&
+		msg.PollUpdateMessage == nil &&
+		msg.EncReactionMessage == nil
+}
+
+func (s *Whatsmiau) cacheMessage(instanceID string, msg *cachedMessage) {
+	if env.Env.MessageCacheSize <= 0 || !canCacheMessage(msg.Message) {
+		return
+	}
+
+	cache, _ := s.messageCache.LoadOrCompute(instanceID, func() (*messageCache, bool) {
+		return &messageCache{
+			order:    make([]string, 0, env.Env.MessageCacheSize),
+			messages: make(map[string]*cachedMessage),
+		}, false
+	})
+
+	cache.add(msg)
+}
+
+func (s *Whatsmiau) cacheReceivedMessage(instanceID string, e *events.Message) {
+	s.cacheMessage(instanceID, &cachedMessage{
+		ID:        e.Info.ID,
+		Chat:      e.Info.Chat,
+		Sender:    e.Info.Sender,
+		FromMe:    e.Info.IsFromMe,
+		Timestamp: e.Info.Timestamp,
+		Message:   e.Message,
+	})
+}
+
+func (s *Whatsmiau) cachedMessage(instanceID, messageID string) (*cachedMessage, bool) {
+	cache, ok := s.messageCache.Load(instanceID)
+	if !ok {
+		return nil, false
+	}
+
+	return cache.get(messageID)
+}
+
+// sendMessage sends the message and caches it, so it can be forwarded later, and stores it when the
+// message store is enabled
+func (s *Whatsmiau) sendMessage(ctx context.Context, client *whatsmeow.Client, instanceID string, to types.JID, message *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	res, err := client.SendMessage(ctx, to, message, extra...)
+	if err != nil {
+		return res, err
+	}
+
+	var sender types.JID
+	if client.Store.ID != nil {
+		sender = client.Store.ID.ToNonAD()
+	}
+
+	s.cacheMessage(instanceID, &cachedMessage{
+		ID:        res.ID,
+		Chat:      to,
+		Sender:    sender,
+		FromMe:    true,
+		Timestamp: res.Timestamp,
+		Message:   message,
+	})
+	s.storeSentMessage(instanceID, to, res, message)
+
+	return res, nil
+}
@@ -0,0 +1,49 @@
+package whatsmiau
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMessageCacheAdd(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		add  []string
+		want []string // kept ids
+	}{
+		{name: "under the size", size: 3, add: []string{"a", "b"}, want: []string{"a", "b"}},
+		{name: "full", size: 3, add: []string{"a", "b", "c"}, want: []string{"a", "b", "c"}},
+		{name: "oldest is dropped", size: 3, add: []string{"a", "b", "c", "d"}, want: []string{"b", "c", "d"}},
+		{name: "ring wraps around", size: 3, add: []string{"a", "b", "c", "d", "e", "f", "g"}, want: []string{"e", "f", "g"}},
+		{name: "same id is replaced in place", size: 3, add: []string{"a", "b", "a", "c", "d"}, want: []string{"b", "c", "d"}},
+		{name: "single slot", size: 1, add: []string{"a", "b"}, want: []string{"b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &messageCache{
+				order:    make([]string, 0, tt.size),
+				messages: make(map[string]*cachedMessage),
+			}
+			for _, id := range tt.add {
+				cache.add(&cachedMessage{ID: id})
+			}
+
+			var kept []string
+			for id := range cache.messages {
+				kept = append(kept, id)
+			}
+			slices.Sort(kept)
+			if !slices.Equal(kept, tt.want) {
+				t.Errorf("kept %v, want %v", kept, tt.want)
+			}
+
+			for _, id := range tt.want {
+				if _, ok := cache.get(id); !ok {
+					t.Errorf("get(%s) not found", id)
+				}
+			}
+		})
+	}
+}
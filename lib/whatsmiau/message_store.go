@@ -0,0 +1,183 @@
+package whatsmiau
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+var ErrMessageStoreDisabled = errors.New("message store is disabled, set MESSAGE_STORE")
+
+// storeMessage keeps the messages.upsert data in the message store, without the base64 of the media
+func (s *Whatsmiau) storeMessage(data *WookMessageData) {
+	if s.messageStore == nil || data == nil || data.Key == nil {
+		return
+	}
+
+	stored := *data
+	if data.Message != nil && len(data.Message.Base64) > 0 {
+		message := *data.Message
+		message.Base64 = ""
+		stored.Message = &message
+	}
+
+	raw, err := json.Marshal(stored)
+	if err != nil {
+		zap.L().Error("failed to marshal stored message", zap.String("id", data.Key.Id), zap.Error(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := s.messageStore.Save(ctx, &models.Message{
+		ID:          data.Key.Id,
+		InstanceID:  data.InstanceId,
+		RemoteJid:   data.Key.RemoteJid,
+		RemoteLid:   data.Key.RemoteLid,
+		FromMe:      data.Key.FromMe,
+		MessageType: data.MessageType,
+		Status:      storedStatus(data),
+		Timestamp:   time.Unix(int64(data.MessageTimestamp), 0),
+		Data:        raw,
+	}); err != nil {
+		zap.L().Error("failed to store message", zap.String("instance", data.InstanceId), zap.String("id", data.Key.Id), zap.Error(err))
+	}
+}
+
+// storedStatus returns the Evolution status of a new message, the upsert data only tells if it was sent
+// or received
+func storedStatus(data *WookMessageData) string {
+	switch {
+	case models.MessageStatusRank(data.Status) > 0:
+		return data.Status
+	case data.Key.FromMe:
+		return string(MessageStatusServerAck)
+	default:
+		return string(MessageStatusDeliveryAck)
+	}
+}
+
+// storeSentMessage stores the messages sent by the api, whatsmeow doesn't emit events for them
+func (s *Whatsmiau) storeSentMessage(instanceID string, to types.JID, res whatsmeow.SendResponse, message *waE2E.Message) {
+	if s.messageStore == nil && s.chatStore == nil {
+		return
+	}
+
+	jid, lid := s.GetJidLid(context.Background(), instanceID, to)
+	messageType, raw, _ := s.parseWAMessage(message)
+
+	data := &WookMessageData{
+		Key: &WookKey{
+			RemoteJid: jid,
+			RemoteLid: lid,
+			FromMe:    true,
+			Id:        res.ID,
+		},
+		Status:           string(MessageStatusServerAck),
+		Message:          raw,
+		MessageType:      messageType,
+		MessageTimestamp: int(res.Timestamp.Unix()),
+		InstanceId:       instanceID,
+	}
+	s.storeMessage(data)
+	s.storeChatMessage(instanceID, to, data)
+}
+
+func (s *Whatsmiau) storeMessageStatus(data *WookMessageUpdateData) {
+	if s.messageStore == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if data.Status == MessageStatusEdited && data.Message != nil {
+		s.storeEditedMessage(ctx, data)
+	}
+
+	if err := s.messageStore.UpdateStatus(ctx, data.InstanceId, data.MessageId, string(data.Status)); err != nil {
+		zap.L().Error("failed to update stored message status", zap.String("instance", data.InstanceId), zap.String("id", data.MessageId), zap.Error(err))
+	}
+}
+
+// storeEditedMessage replaces the content of the stored message with the edited one
+func (s *Whatsmiau) storeEditedMessage(ctx context.Context, data *WookMessageUpdateData) {
+	messages, _, err := s.messageStore.Find(ctx, &models.MessageFilter{
+		InstanceID: data.InstanceId,
+		ID:         data.MessageId,
+		Limit:      1,
+	})
+	if err != nil {
+		zap.L().Error("failed to load edited message", zap.String("instance", data.InstanceId), zap.String("id", data.MessageId), zap.Error(err))
+		return
+	}
+	if len(messages) == 0 {
+		return
+	}
+
+	message := messages[0]
+	var stored WookMessageData
+	if err := json.Unmarshal(message.Data, &stored); err != nil {
+		zap.L().Warn("invalid stored message", zap.String("id", message.ID), zap.Error(err))
+		return
+	}
+
+	// the context of the original message (quote, mentions) is kept
+	stored.Message = data.Message
+	stored.MessageType = data.MessageType
+	raw, err := json.Marshal(stored)
+	if err != nil {
+		zap.L().Error("failed to marshal stored message", zap.String("id", message.ID), zap.Error(err))
+		return
+	}
+
+	message.MessageType = data.MessageType
+	message.Data = raw
+	if err := s.messageStore.Save(ctx, &message); err != nil {
+		zap.L().Error("failed to store edited message", zap.String("instance", data.InstanceId), zap.String("id", data.MessageId), zap.Error(err))
+	}
+}
+
+type FindMessagesResult struct {
+	Total   int
+	Records []WookMessageData
+}
+
+// FindMessages returns a page of the stored messages (newest first) with their last status
+func (s *Whatsmiau) FindMessages(ctx context.Context, filter *models.MessageFilter) (*FindMessagesResult, error) {
+	if s.messageStore == nil {
+		return nil, ErrMessageStoreDisabled
+	}
+
+	messages, total, err := s.messageStore.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FindMessagesResult{
+		Total:   total,
+		Records: make([]WookMessageData, 0, len(messages)),
+	}
+	for _, message := range messages {
+		var data WookMessageData
+		if err := json.Unmarshal(message.Data, &data); err != nil {
+			zap.L().Warn("invalid stored message", zap.String("id", message.ID), zap.Error(err))
+			continue
+		}
+
+		if message.Status != "" {
+			data.Status = message.Status
+		}
+		result.Records = append(result.Records, data)
+	}
+
+	return result, nil
+}
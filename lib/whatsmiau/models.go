@@ -1,6 +1,7 @@
 package whatsmiau
 
 import (
+	"strings"
 	"time"
 
 	"github.com/emersion/go-vcard"
@@ -11,9 +12,51 @@ type Wook string
 const (
 	WookMessagesUpsert Wook = "messages.upsert"
 	WookMessagesUpdate Wook = "messages.update"
+	WookMessagesDelete Wook = "messages.delete"
+	WookMessagesSet    Wook = "messages.set"
 	WookContactsUpsert Wook = "contacts.upsert"
+	WookChatsSet       Wook = "chats.set"
+	WookCall           Wook = "call"
+
+	WookGroupsUpsert            Wook = "groups.upsert"
+	WookGroupsUpdate            Wook = "groups.update"
+	WookGroupParticipantsUpdate Wook = "group-participants.update"
+
+	WookQRCodeUpdated    Wook = "qrcode.updated"
+	WookConnectionUpdate Wook = "connection.update"
+	WookLogoutInstance   Wook = "logout.instance"
 )
 
+// wookEvents lists every event the emitter can produce
+var wookEvents = []Wook{
+	WookMessagesUpsert,
+	WookMessagesUpdate,
+	WookMessagesDelete,
+	WookMessagesSet,
+	WookContactsUpsert,
+	WookChatsSet,
+	WookCall,
+	WookGroupsUpsert,
+	WookGroupsUpdate,
+	WookGroupParticipantsUpdate,
+	WookQRCodeUpdated,
+	WookConnectionUpdate,
+	WookLogoutInstance,
+}
+
+var wookNameReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// Name is the configuration name of the event, ex: messages.upsert is MESSAGES_UPSERT and
+// group-participants.update is GROUP_PARTICIPANTS_UPDATE
+func (w Wook) Name() string {
+	return strings.ToUpper(wookNameReplacer.Replace(string(w)))
+}
+
+// Path is the url suffix used by byEvents webhooks
+func (w Wook) Path() string {
+	return strings.ReplaceAll(string(w), ".", "-")
+}
+
 type WookEvent[data any] struct {
 	Instance    string    `json:"instance,omitempty"`
 	Data        *data     `json:"data,omitempty"`
@@ -98,10 +141,15 @@ type WookMessageRaw struct {
 	ReactionMessage      *ReactionMessageRaw      `json:"reactionMessage,omitempty"`
 	ContactMessage       *ContactMessageRaw       `json:"contactMessage,omitempty"`
 	ContactsArrayMessage *ContactsArrayMessageRaw `json:"contactsArrayMessage,omitempty"`
+	LocationMessage      *WookLocationMessageRaw  `json:"locationMessage,omitempty"`
 	//MessageContextInfo  WookMessageContextInfo `json:"messageContextInfo,omitempty"`
 
-	ListResponseMessage *WookListMessageRaw `json:"listResponseMessage,omitempty"`
-	MediaURL            string              `json:"mediaUrl,omitempty"` // Sent when connect with some storage
+	ListResponseMessage        *WookListMessageRaw                `json:"listResponseMessage,omitempty"`
+	ButtonsResponseMessage     *WookButtonsResponseMessageRaw     `json:"buttonsResponseMessage,omitempty"`
+	InteractiveResponseMessage *WookInteractiveResponseMessageRaw `json:"interactiveResponseMessage,omitempty"`
+	PollCreationMessage        *WookPollCreationMessageRaw        `json:"pollCreationMessage,omitempty"`
+	PollUpdateMessage          *WookPollUpdateMessageRaw          `json:"pollUpdateMessage,omitempty"`
+	MediaURL                   string                             `json:"mediaUrl,omitempty"` // Sent when connect with some storage
 }
 
 type ContactsArrayMessageRaw struct {
@@ -126,6 +174,29 @@ type WookListMessageRaw struct {
 	FooterText        string                                   `json:"footerText,omitempty"`
 }
 
+type WookButtonsResponseMessageRaw struct {
+	SelectedButtonId    string `json:"selectedButtonId,omitempty"`
+	SelectedDisplayText string `json:"selectedDisplayText,omitempty"`
+	Type                string `json:"type,omitempty"`
+}
+
+type WookInteractiveResponseMessageRaw struct {
+	Body                      *WookInteractiveResponseBody      `json:"body,omitempty"`
+	NativeFlowResponseMessage *WookNativeFlowResponseMessageRaw `json:"nativeFlowResponseMessage,omitempty"`
+}
+
+type WookInteractiveResponseBody struct {
+	Text   string `json:"text,omitempty"`
+	Format string `json:"format,omitempty"`
+}
+
+type WookNativeFlowResponseMessageRaw struct {
+	Name       string `json:"name,omitempty"`
+	ParamsJson string `json:"paramsJson,omitempty"`
+	Version    int    `json:"version,omitempty"`
+	SelectedId string `json:"selectedId,omitempty"` // id of the clicked button, from paramsJson
+}
+
 type WookListMessageRawListSingleSelectReply struct {
 	SelectedRowId string `json:"selectedRowId,omitempty"`
 }
@@ -160,6 +231,36 @@ type WookListRow struct {
 	RowId       string `json:"rowId,omitempty"`
 }
 
+type WookLocationMessageRaw struct {
+	DegreesLatitude  float64 `json:"degreesLatitude"`
+	DegreesLongitude float64 `json:"degreesLongitude"`
+	Name             string  `json:"name,omitempty"`
+	Address          string  `json:"address,omitempty"`
+	Url              string  `json:"url,omitempty"`
+	JpegThumbnail    string  `json:"jpegThumbnail,omitempty"`
+}
+
+type WookPollCreationMessageRaw struct {
+	Name                   string           `json:"name,omitempty"`
+	Options                []WookPollOption `json:"options,omitempty"`
+	SelectableOptionsCount int              `json:"selectableOptionsCount"`
+}
+
+type WookPollOption struct {
+	OptionName string `json:"optionName"`
+}
+
+type WookPollUpdateMessageRaw struct {
+	PollCreationMessageKey *WookKey      `json:"pollCreationMessageKey,omitempty"`
+	Vote                   *WookPollVote `json:"vote,omitempty"` // nil when the vote couldn't be decrypted
+	SenderTimestampMs      string        `json:"senderTimestampMs,omitempty"`
+}
+
+type WookPollVote struct {
+	SelectedOptions      []string `json:"selectedOptions"`      // names of the options, when the poll is known
+	SelectedOptionHashes []string `json:"selectedOptionHashes"` // hex SHA-256 of the option names
+}
+
 type ReactionMessageRaw struct {
 	Key               *WookKey `json:"key,omitempty"`
 	Text              string   `json:"text,omitempty"`
@@ -241,8 +342,12 @@ type ContextInfoDisappearingMode struct {
 type WookMessageUpdateStatus string
 
 const (
+	MessageStatusServerAck   WookMessageUpdateStatus = "SERVER_ACK"
 	MessageStatusDeliveryAck WookMessageUpdateStatus = "DELIVERY_ACK"
 	MessageStatusRead        WookMessageUpdateStatus = "READ"
+	MessageStatusPlayed      WookMessageUpdateStatus = "PLAYED"
+	MessageStatusEdited      WookMessageUpdateStatus = "EDITED"
+	MessageStatusDeleted     WookMessageUpdateStatus = "DELETED"
 )
 
 type WookMessageUpdateData struct {
@@ -255,6 +360,8 @@ type WookMessageUpdateData struct {
 	ParticipantLid string                  `json:"participantLid,omitempty"`
 	Status         WookMessageUpdateStatus `json:"status,omitempty"`
 	InstanceId     string                  `json:"instanceId,omitempty"`
+	MessageType    string                  `json:"messageType,omitempty"` // EDITED only
+	Message        *WookMessageRaw         `json:"message,omitempty"`     // EDITED only, the new content
 }
 
 type WookContact struct {
@@ -267,3 +374,147 @@ type WookContact struct {
 }
 
 type WookContactUpsertData []WookContact
+
+// WookMessagesSetData is a batch of messages of the history sync
+type WookMessagesSetData []WookMessageData
+
+type WookChat struct {
+	RemoteJid             string `json:"remoteJid,omitempty"`
+	RemoteLid             string `json:"remoteLid"`
+	Name                  string `json:"name,omitempty"`
+	UnreadMessages        int    `json:"unreadMessages"`
+	Archived              bool   `json:"archived,omitempty"`
+	Pinned                bool   `json:"pinned,omitempty"`
+	ReadOnly              bool   `json:"readOnly,omitempty"`
+	MuteEndTime           int    `json:"muteEndTime,omitempty"`
+	ConversationTimestamp int    `json:"conversationTimestamp,omitempty"`
+	InstanceId            string `json:"instanceId,omitempty"`
+	// LastMessage is only filled by findChats
+	LastMessage *WookChatLastMessage `json:"lastMessage,omitempty"`
+}
+
+type WookChatLastMessage struct {
+	Id               string `json:"id,omitempty"`
+	FromMe           bool   `json:"fromMe,omitempty"`
+	MessageType      string `json:"messageType,omitempty"`
+	Text             string `json:"text,omitempty"`
+	MessageTimestamp int    `json:"messageTimestamp,omitempty"`
+}
+
+// WookChatsSetData is a batch of chats of the history sync
+type WookChatsSetData []WookChat
+
+type WookCallStatus string
+
+const (
+	CallStatusOffer  WookCallStatus = "offer"
+	CallStatusReject WookCallStatus = "reject"
+)
+
+type WookCallData struct {
+	Id         string         `json:"id,omitempty"`
+	From       string         `json:"from,omitempty"`
+	FromLid    string         `json:"fromLid"`
+	GroupJid   string         `json:"groupJid,omitempty"`
+	IsGroup    bool           `json:"isGroup"`
+	IsVideo    bool           `json:"isVideo"`
+	Status     WookCallStatus `json:"status,omitempty"`
+	Date       time.Time      `json:"date"`
+	InstanceId string         `json:"instanceId,omitempty"`
+}
+
+// WookGroup is a group the instance created or was added to
+type WookGroup struct {
+	Group
+	Author     string `json:"author,omitempty"`
+	AuthorLid  string `json:"authorLid,omitempty"`
+	InstanceId string `json:"instanceId,omitempty"`
+}
+
+type WookGroupsUpsertData []WookGroup
+
+// WookGroupUpdate has only the settings changed in the group
+type WookGroupUpdate struct {
+	ID                string  `json:"id"`
+	Author            string  `json:"author,omitempty"`
+	AuthorLid         string  `json:"authorLid,omitempty"`
+	Subject           *string `json:"subject,omitempty"`
+	Desc              *string `json:"desc,omitempty"`
+	Announce          *bool   `json:"announce,omitempty"`
+	Restrict          *bool   `json:"restrict,omitempty"`
+	EphemeralDuration *uint32 `json:"ephemeralDuration,omitempty"` // seconds, 0 is off
+	JoinApprovalMode  *bool   `json:"joinApprovalMode,omitempty"`
+	InviteCode        *string `json:"inviteCode,omitempty"`
+	Deleted           bool    `json:"deleted,omitempty"`
+	Timestamp         int64   `json:"timestamp"`
+	InstanceId        string  `json:"instanceId,omitempty"`
+}
+
+type WookGroupsUpdateData []WookGroupUpdate
+
+type WookGroupParticipantsAction string
+
+const (
+	WookGroupParticipantsAdd     WookGroupParticipantsAction = "add"
+	WookGroupParticipantsRemove  WookGroupParticipantsAction = "remove"
+	WookGroupParticipantsPromote WookGroupParticipantsAction = "promote"
+	WookGroupParticipantsDemote  WookGroupParticipantsAction = "demote"
+)
+
+type WookGroupParticipantsUpdateData struct {
+	ID           string                      `json:"id"`
+	Author       string                      `json:"author,omitempty"`
+	AuthorLid    string                      `json:"authorLid,omitempty"`
+	Participants []GroupParticipant          `json:"participants"`
+	Action       WookGroupParticipantsAction `json:"action"`
+	Reason       string                      `json:"reason,omitempty"` // invite when joined by the invite link
+	Timestamp    int64                       `json:"timestamp"`
+	InstanceId   string                      `json:"instanceId,omitempty"`
+}
+
+type WookQRCodeUpdatedData struct {
+	QRCode WookQRCode `json:"qrcode"`
+}
+
+type WookQRCode struct {
+	Instance    string `json:"instance"`
+	PairingCode string `json:"pairingCode,omitempty"`
+	Code        string `json:"code"`
+	Base64      string `json:"base64"` // png data URI
+}
+
+type WookConnectionState string
+
+const (
+	ConnectionStateOpen       WookConnectionState = "open"
+	ConnectionStateConnecting WookConnectionState = "connecting"
+	ConnectionStateClose      WookConnectionState = "close"
+)
+
+// Status reasons of connection.update, the same codes of the Evolution API (Baileys). Connect failures
+// use the WhatsApp code instead
+const (
+	StatusReasonOpen             = 200
+	StatusReasonLoggedOut        = 401
+	StatusReasonBanned           = 403
+	StatusReasonTimedOut         = 408
+	StatusReasonConnectionClosed = 428
+	StatusReasonReplaced         = 440
+	StatusReasonFailed           = 500
+)
+
+type WookConnectionUpdateData struct {
+	Instance     string              `json:"instance"`
+	Wuid         string              `json:"wuid,omitempty"` // jid of the connected number
+	ProfileName  string              `json:"profileName,omitempty"`
+	State        WookConnectionState `json:"state"`
+	StatusReason int                 `json:"statusReason"`
+	Reason       string              `json:"reason,omitempty"`
+	BanExpiresAt *time.Time          `json:"banExpiresAt,omitempty"`
+}
+
+type WookLogoutData struct {
+	Instance     string `json:"instance"`
+	StatusReason int    `json:"statusReason"`
+	Reason       string `json:"reason,omitempty"`
+}
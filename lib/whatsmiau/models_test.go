@@ -0,0 +1,25 @@
+package whatsmiau
+
+import "testing"
+
+func TestWookNameAndPath(t *testing.T) {
+	tests := []struct {
+		event Wook
+		name  string
+		path  string
+	}{
+		{event: WookMessagesUpsert, name: "MESSAGES_UPSERT", path: "messages-upsert"},
+		{event: WookGroupParticipantsUpdate, name: "GROUP_PARTICIPANTS_UPDATE", path: "group-participants-update"},
+		{event: WookQRCodeUpdated, name: "QRCODE_UPDATED", path: "qrcode-updated"},
+		{event: WookLogoutInstance, name: "LOGOUT_INSTANCE", path: "logout-instance"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.event.Name(); got != tt.name {
+			t.Errorf("%s.Name() = %s, want %s", tt.event, got, tt.name)
+		}
+		if got := tt.event.Path(); got != tt.path {
+			t.Errorf("%s.Path() = %s, want %s", tt.event, got, tt.path)
+		}
+	}
+}
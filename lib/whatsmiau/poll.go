@@ -0,0 +1,57 @@
+package whatsmiau
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+// storePollOptions keeps the option names of a poll for POLL_OPTIONS_TTL, the votes only carry their hashes
+func (s *Whatsmiau) storePollOptions(ctx context.Context, instanceID, pollID string, options []WookPollOption) {
+	names := make([]string, 0, len(options))
+	for _, option := range options {
+		names = append(names, option.OptionName)
+	}
+
+	if err := s.pollOptions.Store(ctx, instanceID, pollID, names); err != nil {
+		zap.L().Error("failed to store poll options", zap.String("id", instanceID), zap.String("poll", pollID), zap.Error(err))
+	}
+}
+
+// decryptPollVote returns the vote with the option names of known polls (sent or received in the last POLL_OPTIONS_TTL)
+func (s *Whatsmiau) decryptPollVote(ctx context.Context, id string, client *whatsmeow.Client, e *events.Message) *WookPollVote {
+	vote, err := client.DecryptPollVote(ctx, e)
+	if err != nil {
+		zap.L().Warn("failed to decrypt poll vote", zap.String("id", id), zap.String("message", e.Info.ID), zap.Error(err))
+		return nil
+	}
+
+	byHash := make(map[string]string)
+	pollID := e.Message.GetPollUpdateMessage().GetPollCreationMessageKey().GetID()
+	names, err := s.pollOptions.Options(ctx, id, pollID)
+	if err != nil {
+		zap.L().Warn("failed to get poll options", zap.String("id", id), zap.String("poll", pollID), zap.Error(err))
+	}
+	for _, name := range names {
+		hash := sha256.Sum256([]byte(name))
+		byHash[hex.EncodeToString(hash[:])] = name
+	}
+
+	result := &WookPollVote{
+		SelectedOptions:      []string{},
+		SelectedOptionHashes: []string{},
+	}
+	for _, selected := range vote.GetSelectedOptions() {
+		hash := hex.EncodeToString(selected)
+		result.SelectedOptionHashes = append(result.SelectedOptionHashes, hash)
+		if name, ok := byHash[hash]; ok {
+			result.SelectedOptions = append(result.SelectedOptions, name)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,219 @@
+package whatsmiau
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+	"golang.org/x/net/html"
+)
+
+const (
+	previewMaxPage      = 512 * 1024 // only the head is needed
+	previewMaxThumbnail = 2 * 1024 * 1024
+	previewMaxRedirects = 5
+)
+
+var previewURLRegex = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+var errPreviewAddress = errors.New("link preview address is not public")
+
+// not covered by netip, ex: CGNAT and the NAT64 prefix that maps to IPv4 addresses
+var previewReservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+type linkPreview struct {
+	URL         string
+	Title       string
+	Description string
+	Thumbnail   []byte // jpeg
+}
+
+// fetchLinkPreview reads the OpenGraph tags (falling back to <title> and the description meta) of the
+// first url of text, returns nil when there is no url or the page has nothing to show
+func (s *Whatsmiau) fetchLinkPreview(ctx context.Context, text string) *linkPreview {
+	matched := strings.TrimRight(previewURLRegex.FindString(text), ".,;:!?)]}'")
+	if matched == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	res, err := s.previewGet(ctx, matched)
+	if err != nil {
+		zap.L().Debug("failed to fetch link preview", zap.String("url", matched), zap.Error(err))
+		return nil
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 || !strings.Contains(res.Header.Get("Content-Type"), "html") {
+		return nil
+	}
+
+	preview := &linkPreview{URL: matched}
+	var title, image string
+	tokenizer := html.NewTokenizer(io.LimitReader(res.Body, previewMaxPage))
+	for done := false; !done; {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			done = true
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			done = string(name) == "head"
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "body":
+				done = true
+			case "title":
+				if tokenizer.Next() == html.TextToken && title == "" {
+					title = strings.TrimSpace(string(tokenizer.Text()))
+				}
+			case "meta":
+				var property, content string
+				for hasAttr {
+					var key, value []byte
+					key, value, hasAttr = tokenizer.TagAttr()
+					switch strings.ToLower(string(key)) {
+					case "property", "name":
+						property = strings.ToLower(string(value))
+					case "content":
+						content = strings.TrimSpace(string(value))
+					}
+				}
+
+				switch property {
+				case "og:title":
+					preview.Title = content
+				case "og:description":
+					preview.Description = content
+				case "description":
+					if preview.Description == "" {
+						preview.Description = content
+					}
+				case "og:image", "og:image:url", "og:image:secure_url":
+					if image == "" {
+						image = content
+					}
+				}
+			}
+		}
+	}
+
+	if preview.Title == "" {
+		preview.Title = title
+	}
+	if preview.Title == "" && preview.Description == "" {
+		return nil
+	}
+
+	if image != "" {
+		if imageURL, err := res.Request.URL.Parse(image); err == nil {
+			preview.Thumbnail = s.fetchPreviewThumbnail(ctx, imageURL.String())
+		}
+	}
+
+	return preview
+}
+
+func (s *Whatsmiau) fetchPreviewThumbnail(ctx context.Context, url string) []byte {
+	res, err := s.previewGet(ctx, url)
+	if err != nil {
+		zap.L().Debug("failed to fetch link preview image", zap.String("url", url), zap.Error(err))
+		return nil
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, previewMaxThumbnail+1))
+	if err != nil || len(data) > previewMaxThumbnail {
+		return nil
+	}
+
+	thumbnail, err := convertThumbnail(ctx, data)
+	if err != nil {
+		zap.L().Debug("failed to convert link preview image", zap.String("url", url), zap.Error(err))
+		return nil
+	}
+
+	return thumbnail
+}
+
+func (s *Whatsmiau) previewGet(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// most sites only render the OpenGraph tags server side for known crawlers
+	req.Header.Set("User-Agent", "WhatsApp/2.23.20.0")
+
+	return s.previewClient.Do(req)
+}
+
+// newPreviewClient only connects to public addresses, the urls come from the sent text. The check runs on
+// each dial, after the DNS resolution, so redirects and rebinding can't reach internal services either
+func newPreviewClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			return checkPublicAddress(address)
+		},
+	}
+
+	return &http.Client{
+		Timeout: 20 * time.Second,
+		Transport: &http.Transport{
+			Proxy:               nil, // a proxy would dial on our behalf
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= previewMaxRedirects {
+				return errors.New("too many redirects")
+			}
+			return nil
+		},
+	}
+}
+
+// checkPublicAddress refuses loopback, private, link local (cloud metadata), CGNAT and other reserved addresses
+func checkPublicAddress(address string) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := addrPort.Addr().Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return fmt.Errorf("%w: %s", errPreviewAddress, ip)
+	}
+
+	for _, prefix := range previewReservedPrefixes {
+		if prefix.Contains(ip) {
+			return fmt.Errorf("%w: %s", errPreviewAddress, ip)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package whatsmiau
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckPublicAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		public  bool
+	}{
+		{address: "93.184.216.34:443", public: true},
+		{address: "[2606:2800:220:1:248:1893:25c8:1946]:443", public: true},
+		{address: "127.0.0.1:80", public: false},
+		{address: "[::1]:80", public: false},
+		{address: "10.0.0.1:80", public: false},
+		{address: "172.16.5.4:80", public: false},
+		{address: "192.168.1.1:80", public: false},
+		{address: "169.254.169.254:80", public: false}, // cloud metadata
+		{address: "100.64.0.1:80", public: false},
+		{address: "0.0.0.0:80", public: false},
+		{address: "[::ffff:127.0.0.1]:80", public: false},
+		{address: "[::ffff:169.254.169.254]:80", public: false},
+		{address: "[fd00::1]:80", public: false},
+		{address: "[fe80::1]:80", public: false},
+		{address: "[64:ff9b::a9fe:a9fe]:80", public: false},
+		{address: "224.0.0.1:80", public: false},
+		{address: "255.255.255.255:80", public: false},
+		{address: "localhost:80", public: false},
+	}
+
+	for _, tt := range tests {
+		if err := checkPublicAddress(tt.address); (err == nil) != tt.public {
+			t.Errorf("checkPublicAddress(%s) error = %v, want public %v", tt.address, err, tt.public)
+		}
+	}
+}
+
+func TestPreviewClientRefusesInternal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><head><title>internal</title></head></html>"))
+	}))
+	defer server.Close()
+
+	_, err := newPreviewClient().Get(server.URL)
+	if !errors.Is(err, errPreviewAddress) {
+		t.Fatalf("Get(%s) error = %v, want %v", server.URL, err, errPreviewAddress)
+	}
+}
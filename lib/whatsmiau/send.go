@@ -1,27 +1,37 @@
 package whatsmiau
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/emersion/go-vcard"
+	"github.com/verbeux-ai/whatsmiau/env"
 	"go.mau.fi/whatsmeow"
 	waBinary "go.mau.fi/whatsmeow/binary"
 	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 type SendText struct {
-	Text           string     `json:"text"`
-	InstanceID     string     `json:"instance_id"`
-	RemoteJID      *types.JID `json:"remote_jid"`
-	QuoteMessageID string     `json:"quote_message_id"`
-	QuoteMessage   string     `json:"quote_message"`
-	Participant    *types.JID `json:"participant"`
+	Text             string      `json:"text"`
+	InstanceID       string      `json:"instance_id"`
+	RemoteJID        *types.JID  `json:"remote_jid"`
+	QuoteMessageID   string      `json:"quote_message_id"`
+	QuoteMessage     string      `json:"quote_message"`
+	Participant      *types.JID  `json:"participant"`
+	Mentioned        []types.JID `json:"mentioned"`
+	MentionsEveryOne bool        `json:"mentions_every_one"` // groups only, mentions all participants
+	LinkPreview      bool        `json:"link_preview"`
 }
 
 type SendTextResponse struct {
@@ -29,6 +39,37 @@ type SendTextResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// mentionedJIDs joins the mentioned jids with the group participants when MentionsEveryOne is set
+func (s *Whatsmiau) mentionedJIDs(ctx context.Context, client *whatsmeow.Client, data *SendText) ([]string, error) {
+	seen := make(map[string]bool)
+	var result []string
+	add := func(jid types.JID) {
+		if jid.IsEmpty() || seen[jid.String()] {
+			return
+		}
+		seen[jid.String()] = true
+		result = append(result, jid.String())
+	}
+
+	for _, jid := range data.Mentioned {
+		add(jid)
+	}
+
+	if data.MentionsEveryOne && data.RemoteJID.Server == types.GroupServer {
+		// sending without the mentions would silently notify nobody
+		group, err := client.GetGroupInfo(ctx, *data.RemoteJID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get group participants to mention: %w", err)
+		}
+
+		for _, participant := range group.Participants {
+			add(participant.JID)
+		}
+	}
+
+	return result, nil
+}
+
 func (s *Whatsmiau) SendText(ctx context.Context, data *SendText) (*SendTextResponse, error) {
 	client, ok := s.clients.Load(data.InstanceID)
 	if !ok {
@@ -43,12 +84,39 @@ func (s *Whatsmiau) SendText(ctx context.Context, data *SendText) (*SendTextResp
 		RemoteJID:      data.RemoteJID,
 	})
 
-	if contextInfo != nil {
+	mentions, err := s.mentionedJIDs(ctx, client, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(mentions) > 0 {
+		if contextInfo == nil {
+			contextInfo = &waE2E.ContextInfo{}
+		}
+		contextInfo.MentionedJID = mentions
+	}
+
+	var preview *linkPreview
+	if data.LinkPreview {
+		preview = s.fetchLinkPreview(ctx, data.Text)
+	}
+
+	if contextInfo != nil || preview != nil {
+		extended := &waE2E.ExtendedTextMessage{
+			Text:        proto.String(data.Text),
+			ContextInfo: contextInfo,
+		}
+
+		if preview != nil {
+			extended.MatchedText = proto.String(preview.URL)
+			extended.Title = proto.String(preview.Title)
+			extended.Description = proto.String(preview.Description)
+			extended.PreviewType = waE2E.ExtendedTextMessage_NONE.Enum()
+			extended.JPEGThumbnail = preview.Thumbnail
+		}
+
 		message = &waE2E.Message{
-			ExtendedTextMessage: &waE2E.ExtendedTextMessage{
-				Text:        proto.String(data.Text),
-				ContextInfo: contextInfo,
-			},
+			ExtendedTextMessage: extended,
 		}
 	} else {
 		message = &waE2E.Message{
@@ -56,7 +124,7 @@ func (s *Whatsmiau) SendText(ctx context.Context, data *SendText) (*SendTextResp
 		}
 	}
 
-	res, err := client.SendMessage(ctx, *data.RemoteJID, message)
+	res, err := s.sendMessage(ctx, client, data.InstanceID, *data.RemoteJID, message)
 	if err != nil {
 		return nil, err
 	}
@@ -69,6 +137,7 @@ func (s *Whatsmiau) SendText(ctx context.Context, data *SendText) (*SendTextResp
 
 type SendAudioRequest struct {
 	AudioURL       string         `json:"text"`
+	AudioFile      io.Reader      `json:"-"` // uploaded file, used instead of AudioURL
 	InstanceID     string         `json:"instance_id"`
 	RemoteJID      *types.JID     `json:"remote_jid"`
 	QuoteMessageID string         `json:"quote_message_id"`
@@ -87,22 +156,24 @@ func (s *Whatsmiau) SendAudio(ctx context.Context, data *SendAudioRequest) (*Sen
 		return nil, whatsmeow.ErrClientIsNil
 	}
 
-	resAudio, err := s.getCtx(ctx, data.AudioURL)
+	release, err := s.acquireMedia(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer release()
 
-	dataBytes, err := io.ReadAll(resAudio.Body)
+	file, err := s.openMedia(ctx, data.AudioURL, data.AudioFile)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 
-	audioData, waveForm, secs, err := convertAudio(dataBytes, 64)
+	audioData, waveForm, secs, err := convertAudio(ctx, file.Name(), 64)
 	if err != nil {
 		return nil, err
 	}
 
-	uploaded, err := client.Upload(ctx, audioData, whatsmeow.MediaAudio)
+	uploaded, err := s.uploadMedia(ctx, client, bytes.NewReader(audioData), whatsmeow.MediaAudio)
 	if err != nil {
 		return nil, err
 	}
@@ -131,7 +202,7 @@ func (s *Whatsmiau) SendAudio(ctx context.Context, data *SendAudioRequest) (*Sen
 		audio.ContextInfo = contextInfo
 	}
 
-	res, err := client.SendMessage(ctx, *data.RemoteJID, &waE2E.Message{
+	res, err := s.sendMessage(ctx, client, data.InstanceID, *data.RemoteJID, &waE2E.Message{
 		AudioMessage: audio,
 	})
 	if err != nil {
@@ -147,6 +218,7 @@ func (s *Whatsmiau) SendAudio(ctx context.Context, data *SendAudioRequest) (*Sen
 type SendDocumentRequest struct {
 	InstanceID     string         `json:"instance_id"`
 	MediaURL       string         `json:"media_url"`
+	MediaFile      io.Reader      `json:"-"` // uploaded file, used instead of MediaURL
 	Caption        string         `json:"caption"`
 	FileName       string         `json:"file_name"`
 	RemoteJID      *types.JID     `json:"remote_jid"`
@@ -167,17 +239,23 @@ func (s *Whatsmiau) SendDocument(ctx context.Context, data *SendDocumentRequest)
 		return nil, whatsmeow.ErrClientIsNil
 	}
 
-	resMedia, err := s.getCtx(ctx, data.MediaURL)
+	release, err := s.acquireMedia(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer release()
 
-	dataBytes, err := io.ReadAll(resMedia.Body)
+	file, err := s.openMedia(ctx, data.MediaURL, data.MediaFile)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
+
+	if data.Mimetype == "" {
+		data.Mimetype = file.mimetype(data.FileName)
+	}
 
-	uploaded, err := client.Upload(ctx, dataBytes, whatsmeow.MediaDocument)
+	uploaded, err := s.uploadMedia(ctx, client, file, whatsmeow.MediaDocument)
 	if err != nil {
 		return nil, err
 	}
@@ -205,7 +283,7 @@ func (s *Whatsmiau) SendDocument(ctx context.Context, data *SendDocumentRequest)
 		doc.ContextInfo = contextInfo
 	}
 
-	res, err := client.SendMessage(ctx, *data.RemoteJID, &waE2E.Message{
+	res, err := s.sendMessage(ctx, client, data.InstanceID, *data.RemoteJID, &waE2E.Message{
 		DocumentMessage: doc,
 	})
 	if err != nil {
@@ -221,6 +299,7 @@ func (s *Whatsmiau) SendDocument(ctx context.Context, data *SendDocumentRequest)
 type SendImageRequest struct {
 	InstanceID     string         `json:"instance_id"`
 	MediaURL       string         `json:"media_url"`
+	MediaFile      io.Reader      `json:"-"` // uploaded file, used instead of MediaURL
 	Caption        string         `json:"caption"`
 	RemoteJID      *types.JID     `json:"remote_jid"`
 	Mimetype       string         `json:"mimetype"`
@@ -240,26 +319,25 @@ func (s *Whatsmiau) SendImage(ctx context.Context, data *SendImageRequest) (*Sen
 		return nil, whatsmeow.ErrClientIsNil
 	}
 
-	resMedia, err := s.getCtx(ctx, data.MediaURL)
+	release, err := s.acquireMedia(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer release()
 
-	dataBytes, err := io.ReadAll(resMedia.Body)
+	file, err := s.openMedia(ctx, data.MediaURL, data.MediaFile)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 
-	uploaded, err := client.Upload(ctx, dataBytes, whatsmeow.MediaImage)
-	if err != nil {
-		return nil, err
+	if data.Mimetype == "" {
+		data.Mimetype = file.mimetype("")
 	}
 
-	if data.Mimetype == "" {
-		data.Mimetype, err = extractMimetype(dataBytes, uploaded.URL)
-		if err != nil {
-			return nil, err
-		}
+	uploaded, err := s.uploadMedia(ctx, client, file, whatsmeow.MediaImage)
+	if err != nil {
+		return nil, err
 	}
 
 	img := &waE2E.ImageMessage{
@@ -284,7 +362,7 @@ func (s *Whatsmiau) SendImage(ctx context.Context, data *SendImageRequest) (*Sen
 		img.ContextInfo = contextInfo
 	}
 
-	res, err := client.SendMessage(ctx, *data.RemoteJID, &waE2E.Message{
+	res, err := s.sendMessage(ctx, client, data.InstanceID, *data.RemoteJID, &waE2E.Message{
 		ImageMessage: img,
 	})
 	if err != nil {
@@ -297,6 +375,191 @@ func (s *Whatsmiau) SendImage(ctx context.Context, data *SendImageRequest) (*Sen
 	}, nil
 }
 
+type SendVideoRequest struct {
+	InstanceID     string         `json:"instance_id"`
+	MediaURL       string         `json:"media_url"`
+	MediaFile      io.Reader      `json:"-"` // uploaded file, used instead of MediaURL
+	Caption        string         `json:"caption"`
+	RemoteJID      *types.JID     `json:"remote_jid"`
+	Mimetype       string         `json:"mimetype"`
+	GifPlayback    bool           `json:"gif_playback"`
+	QuoteMessageID string         `json:"quote_message_id"`
+	QuoteMessage   string         `json:"quote_message"`
+	QuotedMessage  *waE2E.Message `json:"quoted_message,omitempty"`
+}
+
+type SendVideoResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *Whatsmiau) SendVideo(ctx context.Context, data *SendVideoRequest) (*SendVideoResponse, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	release, err := s.acquireMedia(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	file, err := s.openMedia(ctx, data.MediaURL, data.MediaFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if data.Mimetype == "" {
+		data.Mimetype = file.mimetype("")
+	}
+
+	videoPath, info, err := convertVideo(ctx, file.Name(), data.Mimetype)
+	if err != nil {
+		return nil, err
+	}
+	if videoPath != file.Name() {
+		defer os.Remove(videoPath)
+	}
+
+	videoFile, err := os.Open(videoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer videoFile.Close()
+
+	uploaded, err := s.uploadMedia(ctx, client, videoFile, whatsmeow.MediaVideo)
+	if err != nil {
+		return nil, err
+	}
+
+	video := &waE2E.VideoMessage{
+		URL:           proto.String(uploaded.URL),
+		Mimetype:      proto.String("video/mp4"),
+		Caption:       proto.String(data.Caption),
+		FileSHA256:    uploaded.FileSHA256,
+		FileLength:    proto.Uint64(uploaded.FileLength),
+		Seconds:       proto.Uint32(info.Seconds),
+		MediaKey:      uploaded.MediaKey,
+		FileEncSHA256: uploaded.FileEncSHA256,
+		DirectPath:    proto.String(uploaded.DirectPath),
+		GifPlayback:   proto.Bool(data.GifPlayback),
+	}
+
+	if info.Width > 0 && info.Height > 0 {
+		video.Width = proto.Uint32(info.Width)
+		video.Height = proto.Uint32(info.Height)
+	}
+
+	if len(info.Thumbnail) > 0 {
+		video.JPEGThumbnail = info.Thumbnail
+	}
+
+	contextInfo := BuildContextInfoWithQuoted(QuotedMessageParams{
+		QuoteMessageID: data.QuoteMessageID,
+		QuoteMessage:   data.QuoteMessage,
+		RemoteJID:      data.RemoteJID,
+		QuotedMessage:  data.QuotedMessage,
+	})
+
+	if contextInfo != nil {
+		video.ContextInfo = contextInfo
+	}
+
+	res, err := s.sendMessage(ctx, client, data.InstanceID, *data.RemoteJID, &waE2E.Message{
+		VideoMessage: video,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SendVideoResponse{
+		ID:        res.ID,
+		CreatedAt: res.Timestamp,
+	}, nil
+}
+
+type SendStickerRequest struct {
+	InstanceID     string         `json:"instance_id"`
+	MediaURL       string         `json:"media_url"`
+	MediaFile      io.Reader      `json:"-"` // uploaded file, used instead of MediaURL
+	RemoteJID      *types.JID     `json:"remote_jid"`
+	QuoteMessageID string         `json:"quote_message_id"`
+	QuoteMessage   string         `json:"quote_message"`
+	QuotedMessage  *waE2E.Message `json:"quoted_message,omitempty"`
+}
+
+type SendStickerResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *Whatsmiau) SendSticker(ctx context.Context, data *SendStickerRequest) (*SendStickerResponse, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	release, err := s.acquireMedia(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	file, err := s.openMedia(ctx, data.MediaURL, data.MediaFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stickerData, err := convertSticker(ctx, file.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	uploaded, err := s.uploadMedia(ctx, client, bytes.NewReader(stickerData), whatsmeow.MediaImage)
+	if err != nil {
+		return nil, err
+	}
+
+	sticker := &waE2E.StickerMessage{
+		URL:           proto.String(uploaded.URL),
+		Mimetype:      proto.String("image/webp"),
+		FileSHA256:    uploaded.FileSHA256,
+		FileLength:    proto.Uint64(uploaded.FileLength),
+		MediaKey:      uploaded.MediaKey,
+		FileEncSHA256: uploaded.FileEncSHA256,
+		DirectPath:    proto.String(uploaded.DirectPath),
+		Width:         proto.Uint32(512),
+		Height:        proto.Uint32(512),
+		IsAnimated:    proto.Bool(false),
+	}
+
+	contextInfo := BuildContextInfoWithQuoted(QuotedMessageParams{
+		QuoteMessageID: data.QuoteMessageID,
+		QuoteMessage:   data.QuoteMessage,
+		RemoteJID:      data.RemoteJID,
+		QuotedMessage:  data.QuotedMessage,
+	})
+
+	if contextInfo != nil {
+		sticker.ContextInfo = contextInfo
+	}
+
+	res, err := s.sendMessage(ctx, client, data.InstanceID, *data.RemoteJID, &waE2E.Message{
+		StickerMessage: sticker,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SendStickerResponse{
+		ID:        res.ID,
+		CreatedAt: res.Timestamp,
+	}, nil
+}
+
 type SendReactionRequest struct {
 	InstanceID string     `json:"instance_id"`
 	Reaction   string     `json:"reaction"`
@@ -380,6 +643,9 @@ type SendButtonsResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// ErrInvalidButtons wraps the errors caused by the buttons sent, not by WhatsApp
+var ErrInvalidButtons = errors.New("invalid buttons")
+
 // allReply retorna true se todos os botões são do tipo "reply"
 func allReply(buttons []ButtonItem) bool {
 	for _, b := range buttons {
@@ -397,7 +663,7 @@ func (s *Whatsmiau) SendButtons(ctx context.Context, data *SendButtonsRequest) (
 	}
 
 	if len(data.Buttons) == 0 {
-		return nil, fmt.Errorf("buttons: lista vazia")
+		return nil, fmt.Errorf("%w: lista vazia", ErrInvalidButtons)
 	}
 
 	contextInfo := BuildContextInfoWithQuoted(QuotedMessageParams{
@@ -418,7 +684,7 @@ func (s *Whatsmiau) SendButtons(ctx context.Context, data *SendButtonsRequest) (
 	if len(data.Buttons) == 1 && data.Buttons[0].Type == "pix" {
 		msg, err = buildPixButton(data, contextInfo)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %w", ErrInvalidButtons, err)
 		}
 		extraNodes = []waBinary.Node{{
 			Tag: "biz",
@@ -430,7 +696,7 @@ func (s *Whatsmiau) SendButtons(ctx context.Context, data *SendButtonsRequest) (
 		// tipo "reply": ButtonsMessage + nodes nested native_flow
 		msg, err = buildReplyButtons(data, contextInfo)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %w", ErrInvalidButtons, err)
 		}
 		extraNodes = []waBinary.Node{{
 			Tag: "biz",
@@ -453,7 +719,7 @@ func (s *Whatsmiau) SendButtons(ctx context.Context, data *SendButtonsRequest) (
 		// tipos "copy", "url", "call": InteractiveMessage + nodes nested native_flow
 		msg, err = buildInteractiveButtons(data, contextInfo)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %w", ErrInvalidButtons, err)
 		}
 		extraNodes = []waBinary.Node{{
 			Tag: "biz",
@@ -474,7 +740,7 @@ func (s *Whatsmiau) SendButtons(ctx context.Context, data *SendButtonsRequest) (
 		}}
 	}
 
-	res, err := client.SendMessage(ctx, *data.RemoteJID, msg, whatsmeow.SendRequestExtra{
+	res, err := s.sendMessage(ctx, client, data.InstanceID, *data.RemoteJID, msg, whatsmeow.SendRequestExtra{
 		AdditionalNodes: &extraNodes,
 	})
 	if err != nil {
@@ -774,3 +1040,615 @@ func buildInteractiveButtons(data *SendButtonsRequest, contextInfo *waE2E.Contex
 		},
 	}, nil
 }
+
+// ── SendList ──────────────────────────────────────────────────────────────────
+
+type ListRow struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	RowID       string `json:"rowId"`
+}
+
+type ListSection struct {
+	Title string    `json:"title"`
+	Rows  []ListRow `json:"rows"`
+}
+
+type SendListRequest struct {
+	InstanceID     string        `json:"instance_id"`
+	RemoteJID      *types.JID    `json:"remote_jid"`
+	Title          string        `json:"title"`
+	Description    string        `json:"description"`
+	ButtonText     string        `json:"button_text"`
+	FooterText     string        `json:"footer_text"`
+	Sections       []ListSection `json:"sections"`
+	QuoteMessageID string        `json:"quote_message_id"`
+	QuoteMessage   string        `json:"quote_message"`
+	Participant    *types.JID    `json:"participant"`
+}
+
+type SendListResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *Whatsmiau) SendList(ctx context.Context, data *SendListRequest) (*SendListResponse, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	sections := make([]*waE2E.ListMessage_Section, 0, len(data.Sections))
+	for i, section := range data.Sections {
+		rows := make([]*waE2E.ListMessage_Row, 0, len(section.Rows))
+		for j, row := range section.Rows {
+			rowID := strings.TrimSpace(row.RowID)
+			if rowID == "" {
+				rowID = fmt.Sprintf("row_%d_%d", i, j)
+			}
+			rows = append(rows, &waE2E.ListMessage_Row{
+				Title:       proto.String(row.Title),
+				Description: proto.String(row.Description),
+				RowID:       proto.String(rowID),
+			})
+		}
+		sections = append(sections, &waE2E.ListMessage_Section{
+			Title: proto.String(section.Title),
+			Rows:  rows,
+		})
+	}
+
+	contextInfo := BuildContextInfoWithQuoted(QuotedMessageParams{
+		QuoteMessageID: data.QuoteMessageID,
+		QuoteMessage:   data.QuoteMessage,
+		RemoteJID:      data.RemoteJID,
+		Participant:    data.Participant,
+	})
+
+	msg := &waE2E.Message{
+		ListMessage: &waE2E.ListMessage{
+			Title:       proto.String(data.Title),
+			Description: proto.String(data.Description),
+			ButtonText:  proto.String(data.ButtonText),
+			FooterText:  proto.String(data.FooterText),
+			ListType:    waE2E.ListMessage_SINGLE_SELECT.Enum(),
+			Sections:    sections,
+			ContextInfo: contextInfo,
+		},
+	}
+
+	// sem o node biz o WhatsApp não renderiza a lista no celular
+	extraNodes := []waBinary.Node{{
+		Tag: "biz",
+		Content: []waBinary.Node{{
+			Tag: "list",
+			Attrs: waBinary.Attrs{
+				"type": "product_list",
+				"v":    "2",
+			},
+		}},
+	}}
+
+	res, err := s.sendMessage(ctx, client, data.InstanceID, *data.RemoteJID, msg, whatsmeow.SendRequestExtra{
+		AdditionalNodes: &extraNodes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SendListResponse{
+		ID:        res.ID,
+		CreatedAt: res.Timestamp,
+	}, nil
+}
+
+// ── SendLocation ──────────────────────────────────────────────────────────────
+
+type SendLocationRequest struct {
+	InstanceID     string     `json:"instance_id"`
+	RemoteJID      *types.JID `json:"remote_jid"`
+	Latitude       float64    `json:"latitude"`
+	Longitude      float64    `json:"longitude"`
+	Name           string     `json:"name"`
+	Address        string     `json:"address"`
+	QuoteMessageID string     `json:"quote_message_id"`
+	QuoteMessage   string     `json:"quote_message"`
+	Participant    *types.JID `json:"participant"`
+}
+
+type SendLocationResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *Whatsmiau) SendLocation(ctx context.Context, data *SendLocationRequest) (*SendLocationResponse, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	contextInfo := BuildContextInfoWithQuoted(QuotedMessageParams{
+		QuoteMessageID: data.QuoteMessageID,
+		QuoteMessage:   data.QuoteMessage,
+		RemoteJID:      data.RemoteJID,
+		Participant:    data.Participant,
+	})
+
+	res, err := s.sendMessage(ctx, client, data.InstanceID, *data.RemoteJID, &waE2E.Message{
+		LocationMessage: &waE2E.LocationMessage{
+			DegreesLatitude:  proto.Float64(data.Latitude),
+			DegreesLongitude: proto.Float64(data.Longitude),
+			Name:             proto.String(data.Name),
+			Address:          proto.String(data.Address),
+			ContextInfo:      contextInfo,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SendLocationResponse{
+		ID:        res.ID,
+		CreatedAt: res.Timestamp,
+	}, nil
+}
+
+// ── SendContact ───────────────────────────────────────────────────────────────
+
+type ContactCard struct {
+	FullName     string         `json:"fullName"`
+	Phones       []ContactPhone `json:"phones"`
+	Organization string         `json:"organization"`
+	Email        string         `json:"email"`
+	URL          string         `json:"url"`
+}
+
+type ContactPhone struct {
+	Number string `json:"number"` // as displayed, ex: +55 11 99999-9999
+	WaID   string `json:"waId"`   // digits of the WhatsApp account, taken from Number when empty
+}
+
+type SendContactRequest struct {
+	InstanceID     string        `json:"instance_id"`
+	RemoteJID      *types.JID    `json:"remote_jid"`
+	Contacts       []ContactCard `json:"contacts"`
+	QuoteMessageID string        `json:"quote_message_id"`
+	QuoteMessage   string        `json:"quote_message"`
+	Participant    *types.JID    `json:"participant"`
+}
+
+type SendContactResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var ErrInvalidContact = errors.New("invalid contact")
+
+// buildVCard encodes the contact like the WhatsApp apps, the waid param makes the "message" button work
+func buildVCard(contact ContactCard) (string, error) {
+	if strings.TrimSpace(contact.FullName) == "" {
+		return "", fmt.Errorf("%w: fullName is required", ErrInvalidContact)
+	}
+
+	card := vcard.Card{}
+	card.SetValue(vcard.FieldVersion, "3.0")
+	card.SetValue(vcard.FieldFormattedName, contact.FullName)
+	card.SetName(&vcard.Name{GivenName: contact.FullName})
+
+	for _, phone := range contact.Phones {
+		waID := onlyDigits(phone.WaID)
+		if waID == "" {
+			waID = onlyDigits(phone.Number)
+		}
+		if waID == "" {
+			return "", fmt.Errorf("%w: invalid phone %q of %s", ErrInvalidContact, phone.Number, contact.FullName)
+		}
+
+		number := phone.Number
+		if number == "" {
+			number = "+" + waID
+		}
+
+		card.Add(vcard.FieldTelephone, &vcard.Field{
+			Value: number,
+			Params: vcard.Params{
+				vcard.ParamType: {vcard.TypeCell, vcard.TypeVoice},
+				"waid":          {waID},
+			},
+		})
+	}
+
+	if contact.Organization != "" {
+		card.SetValue(vcard.FieldOrganization, contact.Organization)
+	}
+	if contact.Email != "" {
+		card.SetValue(vcard.FieldEmail, contact.Email)
+	}
+	if contact.URL != "" {
+		card.SetValue(vcard.FieldURL, contact.URL)
+	}
+
+	var buf strings.Builder
+	if err := vcard.NewEncoder(&buf).Encode(card); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidContact, err)
+	}
+
+	return buf.String(), nil
+}
+
+func onlyDigits(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, value)
+}
+
+func (s *Whatsmiau) SendContact(ctx context.Context, data *SendContactRequest) (*SendContactResponse, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if len(data.Contacts) == 0 {
+		return nil, fmt.Errorf("%w: no contacts", ErrInvalidContact)
+	}
+
+	contextInfo := BuildContextInfoWithQuoted(QuotedMessageParams{
+		QuoteMessageID: data.QuoteMessageID,
+		QuoteMessage:   data.QuoteMessage,
+		RemoteJID:      data.RemoteJID,
+		Participant:    data.Participant,
+	})
+
+	contacts := make([]*waE2E.ContactMessage, 0, len(data.Contacts))
+	for _, contact := range data.Contacts {
+		card, err := buildVCard(contact)
+		if err != nil {
+			return nil, err
+		}
+
+		contacts = append(contacts, &waE2E.ContactMessage{
+			DisplayName: proto.String(contact.FullName),
+			Vcard:       proto.String(card),
+		})
+	}
+
+	msg := &waE2E.Message{}
+	if len(contacts) == 1 {
+		contacts[0].ContextInfo = contextInfo
+		msg.ContactMessage = contacts[0]
+	} else {
+		msg.ContactsArrayMessage = &waE2E.ContactsArrayMessage{
+			DisplayName: proto.String(fmt.Sprintf("%d contacts", len(contacts))),
+			Contacts:    contacts,
+			ContextInfo: contextInfo,
+		}
+	}
+
+	res, err := s.sendMessage(ctx, client, data.InstanceID, *data.RemoteJID, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SendContactResponse{
+		ID:        res.ID,
+		CreatedAt: res.Timestamp,
+	}, nil
+}
+
+// ── SendPoll ──────────────────────────────────────────────────────────────────
+
+type SendPollRequest struct {
+	InstanceID      string     `json:"instance_id"`
+	RemoteJID       *types.JID `json:"remote_jid"`
+	Name            string     `json:"name"`
+	Options         []string   `json:"options"`
+	SelectableCount int        `json:"selectable_count"` // 0 allows any number of options
+	QuoteMessageID  string     `json:"quote_message_id"`
+	QuoteMessage    string     `json:"quote_message"`
+	Participant     *types.JID `json:"participant"`
+}
+
+type SendPollResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var ErrInvalidPoll = errors.New("invalid poll")
+
+func (s *Whatsmiau) SendPoll(ctx context.Context, data *SendPollRequest) (*SendPollResponse, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if len(data.Options) < 2 {
+		return nil, fmt.Errorf("%w: at least 2 options are required", ErrInvalidPoll)
+	}
+
+	// votes are hashes of the option name, repeated names can't be told apart
+	seen := make(map[string]bool, len(data.Options))
+	for _, option := range data.Options {
+		if seen[option] {
+			return nil, fmt.Errorf("%w: repeated option %q", ErrInvalidPoll, option)
+		}
+		seen[option] = true
+	}
+
+	if data.SelectableCount < 0 || data.SelectableCount > len(data.Options) {
+		return nil, fmt.Errorf("%w: selectableCount must be between 0 and the number of options", ErrInvalidPoll)
+	}
+
+	msg := client.BuildPollCreation(data.Name, data.Options, data.SelectableCount)
+	msg.PollCreationMessage.ContextInfo = BuildContextInfoWithQuoted(QuotedMessageParams{
+		QuoteMessageID: data.QuoteMessageID,
+		QuoteMessage:   data.QuoteMessage,
+		RemoteJID:      data.RemoteJID,
+		Participant:    data.Participant,
+	})
+
+	res, err := s.sendMessage(ctx, client, data.InstanceID, *data.RemoteJID, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	options := make([]WookPollOption, 0, len(data.Options))
+	for _, option := range data.Options {
+		options = append(options, WookPollOption{OptionName: option})
+	}
+	s.storePollOptions(ctx, data.InstanceID, res.ID, options)
+
+	return &SendPollResponse{
+		ID:        res.ID,
+		CreatedAt: res.Timestamp,
+	}, nil
+}
+
+// ── EditMessage / RevokeMessage ───────────────────────────────────────────────
+
+type EditMessageRequest struct {
+	InstanceID string     `json:"instance_id"`
+	RemoteJID  *types.JID `json:"remote_jid"`
+	MessageID  string     `json:"message_id"`
+	Text       string     `json:"text"`
+}
+
+type EditMessageResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EditMessage replaces the text of a message sent by the instance, WhatsApp only accepts it within
+// whatsmeow.EditWindow of the original message
+func (s *Whatsmiau) EditMessage(ctx context.Context, data *EditMessageRequest) (*EditMessageResponse, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	edited := &waE2E.Message{
+		Conversation: proto.String(data.Text),
+	}
+	res, err := client.SendMessage(ctx, *data.RemoteJID, client.BuildEdit(*data.RemoteJID, data.MessageID, edited))
+	if err != nil {
+		return nil, err
+	}
+
+	messageType, raw, _ := s.parseWAMessage(edited)
+	s.storeMessageStatus(&WookMessageUpdateData{
+		MessageId:   data.MessageID,
+		Status:      MessageStatusEdited,
+		InstanceId:  data.InstanceID,
+		MessageType: messageType,
+		Message:     raw,
+	})
+
+	return &EditMessageResponse{
+		ID:        res.ID,
+		CreatedAt: res.Timestamp,
+	}, nil
+}
+
+type RevokeMessageRequest struct {
+	InstanceID string     `json:"instance_id"`
+	RemoteJID  *types.JID `json:"remote_jid"`
+	MessageID  string     `json:"message_id"`
+	FromMe     bool       `json:"from_me"`
+	// Participant is the sender of the message, used by group admins to delete messages of others
+	Participant *types.JID `json:"participant"`
+}
+
+type RevokeMessageResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RevokeMessage deletes the message for everyone
+func (s *Whatsmiau) RevokeMessage(ctx context.Context, data *RevokeMessageRequest) (*RevokeMessageResponse, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	sender := types.EmptyJID
+	if !data.FromMe && data.Participant != nil {
+		sender = *data.Participant
+	}
+
+	res, err := client.SendMessage(ctx, *data.RemoteJID, client.BuildRevoke(*data.RemoteJID, sender, data.MessageID))
+	if err != nil {
+		return nil, err
+	}
+
+	s.storeMessageStatus(&WookMessageUpdateData{
+		MessageId:  data.MessageID,
+		Status:     MessageStatusDeleted,
+		InstanceId: data.InstanceID,
+	})
+
+	return &RevokeMessageResponse{
+		ID:        res.ID,
+		CreatedAt: res.Timestamp,
+	}, nil
+}
+
+// ── ForwardMessage ────────────────────────────────────────────────────────────
+
+// forwardMediaMaxAge is how long the media of a message is reused when forwarding, WhatsApp removes
+// uploaded media from its servers after a while so older media is uploaded again
+const forwardMediaMaxAge = 7 * 24 * time.Hour
+
+type ForwardMessageRequest struct {
+	InstanceID string      `json:"instance_id"`
+	MessageID  string      `json:"message_id"`
+	RemoteJIDs []types.JID `json:"remote_jids"`
+}
+
+type ForwardMessageResult struct {
+	RemoteJID types.JID `json:"remote_jid"`
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Err       error     `json:"-"`
+}
+
+var ErrMessageNotCached = errors.New("message not found, only messages sent or received recently can be forwarded")
+
+// ForwardMessage sends a copy of a cached message, flagged as forwarded, to each jid. The result of
+// every jid is returned, failing ones have Err set
+func (s *Whatsmiau) ForwardMessage(ctx context.Context, data *ForwardMessageRequest) ([]ForwardMessageResult, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	cached, ok := s.cachedMessage(data.InstanceID, data.MessageID)
+	if !ok {
+		return nil, ErrMessageNotCached
+	}
+
+	msg, err := s.buildForward(ctx, client, cached)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ForwardMessageResult, 0, len(data.RemoteJIDs))
+	for _, jid := range data.RemoteJIDs {
+		// whatsmeow may change the message while sending
+		res, err := s.sendMessage(ctx, client, data.InstanceID, jid, proto.Clone(msg).(*waE2E.Message))
+		results = append(results, ForwardMessageResult{
+			RemoteJID: jid,
+			ID:        res.ID,
+			CreatedAt: res.Timestamp,
+			Err:       err,
+		})
+	}
+
+	return results, nil
+}
+
+// buildForward copies the cached message replacing its context info by the forwarded flag
+func (s *Whatsmiau) buildForward(ctx context.Context, client *whatsmeow.Client, cached *cachedMessage) (*waE2E.Message, error) {
+	msg := proto.Clone(cached.Message).(*waE2E.Message)
+	msg.MessageContextInfo = nil
+	msg.SenderKeyDistributionMessage = nil // of the original group, whatsmeow sends a new one when needed
+
+	// plain text has no context info
+	if msg.Conversation != nil {
+		msg = &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{Text: msg.Conversation}}
+	}
+
+	content := messageContent(msg)
+	if content == nil {
+		return nil, fmt.Errorf("message %s has no content to forward", cached.ID)
+	}
+
+	if time.Since(cached.Timestamp) > forwardMediaMaxAge {
+		if err := s.reuploadMedia(ctx, client, content); err != nil {
+			zap.L().Warn("failed to upload forwarded media again, reusing the original", zap.String("message", cached.ID), zap.Error(err))
+		}
+	}
+
+	if field := content.Descriptor().Fields().ByName("contextInfo"); field != nil {
+		previous, _ := content.Get(field).Message().Interface().(*waE2E.ContextInfo)
+		content.Set(field, protoreflect.ValueOfMessage((&waE2E.ContextInfo{
+			IsForwarded:     proto.Bool(true),
+			ForwardingScore: proto.Uint32(previous.GetForwardingScore() + 1),
+		}).ProtoReflect()))
+	}
+
+	return msg, nil
+}
+
+// nonContentFields are sent along the content, group messages carry a senderKeyDistributionMessage
+var nonContentFields = map[protoreflect.Name]bool{
+	"senderKeyDistributionMessage": true,
+	"messageContextInfo":           true,
+}
+
+// messageContent returns the set content of the message (imageMessage, extendedTextMessage...), the fields
+// are checked in the declaration order since Range has none
+func messageContent(msg *waE2E.Message) protoreflect.Message {
+	reflected := msg.ProtoReflect()
+	fields := reflected.Descriptor().Fields()
+	for i := range fields.Len() {
+		field := fields.Get(i)
+		if field.Message() == nil || field.IsList() || field.IsMap() || nonContentFields[field.Name()] || !reflected.Has(field) {
+			continue
+		}
+
+		return reflected.Get(field).Message()
+	}
+
+	return nil
+}
+
+// reuploadMedia downloads the media of the content and uploads it again, replacing its media fields
+func (s *Whatsmiau) reuploadMedia(ctx context.Context, client *whatsmeow.Client, content protoreflect.Message) error {
+	media, ok := content.Interface().(whatsmeow.DownloadableMessage)
+	if !ok {
+		return nil
+	}
+
+	release, err := s.acquireMedia(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	tmpFile, err := os.CreateTemp("", "forward-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	downloadCtx, cancel := context.WithTimeout(ctx, env.Env.MediaDownloadTimeout)
+	defer cancel()
+
+	if err := client.DownloadToFile(downloadCtx, media, tmpFile); err != nil {
+		return err
+	}
+
+	if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	uploaded, err := s.uploadMedia(ctx, client, tmpFile, whatsmeow.GetMediaType(media))
+	if err != nil {
+		return err
+	}
+
+	// every downloadable message shares these field names
+	fields := content.Descriptor().Fields()
+	content.Set(fields.ByName("URL"), protoreflect.ValueOfString(uploaded.URL))
+	content.Set(fields.ByName("directPath"), protoreflect.ValueOfString(uploaded.DirectPath))
+	content.Set(fields.ByName("mediaKey"), protoreflect.ValueOfBytes(uploaded.MediaKey))
+	content.Set(fields.ByName("fileEncSHA256"), protoreflect.ValueOfBytes(uploaded.FileEncSHA256))
+	content.Set(fields.ByName("fileSHA256"), protoreflect.ValueOfBytes(uploaded.FileSHA256))
+	content.Set(fields.ByName("fileLength"), protoreflect.ValueOfUint64(uploaded.FileLength))
+	content.Set(fields.ByName("mediaKeyTimestamp"), protoreflect.ValueOfInt64(time.Now().Unix()))
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package whatsmiau
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMessageContent(t *testing.T) {
+	skdm := &waE2E.SenderKeyDistributionMessage{GroupID: proto.String("123@g.us")}
+	contextInfo := &waE2E.MessageContextInfo{}
+	image := &waE2E.ImageMessage{}
+	text := &waE2E.ExtendedTextMessage{Text: proto.String("hi")}
+	document := &waE2E.DocumentMessage{}
+
+	tests := []struct {
+		name string
+		msg  *waE2E.Message
+		want proto.Message // nil when there is no content
+	}{
+		{name: "image", msg: &waE2E.Message{ImageMessage: image}, want: image},
+		{name: "group message with sender key", msg: &waE2E.Message{SenderKeyDistributionMessage: skdm, ExtendedTextMessage: text}, want: text},
+		{name: "with message context info", msg: &waE2E.Message{MessageContextInfo: contextInfo, DocumentMessage: document}, want: document},
+		{name: "only sender key", msg: &waE2E.Message{SenderKeyDistributionMessage: skdm, MessageContextInfo: contextInfo}},
+		{name: "conversation is not a message field", msg: &waE2E.Message{Conversation: proto.String("hi")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := messageContent(tt.msg)
+			if tt.want == nil {
+				if content != nil {
+					t.Errorf("messageContent() = %v, want nil", content.Interface())
+				}
+				return
+			}
+
+			if content == nil || content.Interface() != tt.want {
+				t.Errorf("messageContent() is not the %s", tt.want.ProtoReflect().Descriptor().Name())
+			}
+		})
+	}
+}
+
+func TestBuildVCard(t *testing.T) {
+	type phone struct {
+		number string
+		waID   string
+	}
+
+	tests := []struct {
+		name    string
+		contact ContactCard
+		phones  []phone
+		org     string
+		email   string
+		url     string
+		err     error
+	}{
+		{
+			name:    "number with formatting",
+			contact: ContactCard{FullName: "Maria", Phones: []ContactPhone{{Number: "+55 11 99999-9999"}}},
+			phones:  []phone{{number: "+55 11 99999-9999", waID: "5511999999999"}},
+		},
+		{
+			name:    "waId without number",
+			contact: ContactCard{FullName: "Maria", Phones: []ContactPhone{{WaID: "5511999999999"}}},
+			phones:  []phone{{number: "+5511999999999", waID: "5511999999999"}},
+		},
+		{
+			name:    "waId differs from the number",
+			contact: ContactCard{FullName: "Maria", Phones: []ContactPhone{{Number: "(11) 3333-4444", WaID: "+55 11 99999-9999"}}},
+			phones:  []phone{{number: "(11) 3333-4444", waID: "5511999999999"}},
+		},
+		{
+			name: "several phones and the optional fields",
+			contact: ContactCard{
+				FullName:     "Maria; Silva",
+				Phones:       []ContactPhone{{Number: "+5511999999999"}, {Number: "+5521988888888"}},
+				Organization: "Acme",
+				Email:        "maria@example.com",
+				URL:          "https://example.com",
+			},
+			phones: []phone{{number: "+5511999999999", waID: "5511999999999"}, {number: "+5521988888888", waID: "5521988888888"}},
+			org:    "Acme",
+			email:  "maria@example.com",
+			url:    "https://example.com",
+		},
+		{
+			name:    "without phones",
+			contact: ContactCard{FullName: "Maria"},
+		},
+		{name: "without name", contact: ContactCard{FullName: " ", Phones: []ContactPhone{{Number: "+5511999999999"}}}, err: ErrInvalidContact},
+		{name: "phone without digits", contact: ContactCard{FullName: "Maria", Phones: []ContactPhone{{Number: "abc"}}}, err: ErrInvalidContact},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := buildVCard(tt.contact)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("buildVCard() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildVCard() error: %v", err)
+			}
+
+			card, err := vcard.NewDecoder(strings.NewReader(raw)).Decode()
+			if err != nil {
+				t.Fatalf("invalid vcard %q: %v", raw, err)
+			}
+
+			if got := card.Value(vcard.FieldVersion); got != "3.0" {
+				t.Errorf("version = %s, want 3.0", got)
+			}
+			if got := card.Value(vcard.FieldFormattedName); got != tt.contact.FullName {
+				t.Errorf("FN = %q, want %q", got, tt.contact.FullName)
+			}
+
+			var phones []phone
+			for _, field := range card[vcard.FieldTelephone] {
+				// the decoder upper cases the param names
+				phones = append(phones, phone{number: field.Value, waID: field.Params.Get("WAID")})
+			}
+			if !reflect.DeepEqual(phones, tt.phones) {
+				t.Errorf("phones = %+v, want %+v", phones, tt.phones)
+			}
+
+			if got := card.Value(vcard.FieldOrganization); got != tt.org {
+				t.Errorf("ORG = %q, want %q", got, tt.org)
+			}
+			if got := card.Value(vcard.FieldEmail); got != tt.email {
+				t.Errorf("EMAIL = %q, want %q", got, tt.email)
+			}
+			if got := card.Value(vcard.FieldURL); got != tt.url {
+				t.Errorf("URL = %q, want %q", got, tt.url)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package whatsmiau
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+)
+
+// streamResumeWindow keeps events flowing to the buffer after the last subscriber leaves, so a
+// reconnecting client can resume from its cursor without losing events. The stream is removed after it
+const streamResumeWindow = 2 * time.Minute
+
+// WookStreamGap is only sent to live subscribers, before the replay, when events after their cursor were
+// lost (evicted from the buffer, emitted while nobody was streaming or before a restart). Its cursor
+// resumes from the oldest buffered event
+const WookStreamGap Wook = "stream.gap"
+
+// StreamEvent is an emitted event as delivered to live subscribers (SSE/WebSocket)
+type StreamEvent struct {
+	Cursor string          `json:"cursor"`
+	Event  Wook            `json:"event"`
+	Data   json.RawMessage `json:"data"`
+
+	seq uint64
+}
+
+// StreamGap is the data of WookStreamGap
+type StreamGap struct {
+	Cursor string `json:"cursor"` // the cursor sent by the client
+}
+
+type streamSubscriber struct {
+	events chan StreamEvent
+	filter map[string]bool
+}
+
+func (s *streamSubscriber) accepts(event Wook) bool {
+	return len(s.filter) == 0 || s.filter[event.Name()]
+}
+
+type eventStream struct {
+	mu          sync.Mutex
+	since       uint64 // events before it were emitted while the stream didn't exist
+	evicted     uint64 // last event dropped from the buffer
+	buffer      []StreamEvent
+	subscribers map[*streamSubscriber]struct{}
+	lastActive  time.Time
+	removed     bool
+}
+
+func (s *Whatsmiau) streamCursor(seq uint64) string {
+	return fmt.Sprintf("%d-%d", s.streamEpoch, seq)
+}
+
+// parseStreamCursor returns the sequence of the cursor, cursors from another process run are ignored
+func (s *Whatsmiau) parseStreamCursor(cursor string) (uint64, bool) {
+	epoch, seq, ok := strings.Cut(cursor, "-")
+	if !ok || epoch != strconv.FormatInt(s.streamEpoch, 10) {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// streaming returns true while the instance has subscribers (or had them recently)
+func (s *Whatsmiau) streaming(instanceID string) bool {
+	stream, ok := s.streams.Load(instanceID)
+	if !ok {
+		return false
+	}
+
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+
+	return len(stream.subscribers) > 0 || time.Since(stream.lastActive) < streamResumeWindow
+}
+
+func (s *Whatsmiau) broadcast(instanceID string, event Wook, data []byte) {
+	stream, ok := s.streams.Load(instanceID)
+	if !ok {
+		return
+	}
+
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+
+	seq := s.streamSeq.Add(1)
+	streamEvent := StreamEvent{
+		Cursor: s.streamCursor(seq),
+		Event:  event,
+		Data:   data,
+		seq:    seq,
+	}
+
+	stream.buffer = append(stream.buffer, streamEvent)
+	if over := len(stream.buffer) - env.Env.StreamBufferSize; over > 0 {
+		stream.evicted = stream.buffer[over-1].seq
+		stream.buffer = stream.buffer[over:]
+	}
+
+	for subscriber := range stream.subscribers {
+		if !subscriber.accepts(event) {
+			continue
+		}
+
+		select {
+		case subscriber.events <- streamEvent:
+		default:
+			// slow subscriber, closing it makes the client reconnect and resume from its cursor
+			delete(stream.subscribers, subscriber)
+			close(subscriber.events)
+		}
+	}
+}
+
+// Subscribe returns the live events of the instance, starting after the cursor when it is still buffered.
+// A WookStreamGap event comes first when events after the cursor were lost. The channel is closed if the
+// subscriber can't keep up, the returned func must be called when done
+func (s *Whatsmiau) Subscribe(instanceID, cursor string, events []string) (<-chan StreamEvent, func()) {
+	filter := make(map[string]bool)
+	for _, event := range events {
+		if event = strings.TrimSpace(event); event != "" {
+			filter[Wook(event).Name()] = true // accepts messages.upsert or MESSAGES_UPSERT
+		}
+	}
+
+	stream := s.lockStream(instanceID)
+	defer stream.mu.Unlock()
+
+	var replay []StreamEvent
+	if cursor != "" {
+		after, ok := s.parseStreamCursor(cursor)
+		if !ok || after < max(stream.since, stream.evicted) {
+			replay = append(replay, s.streamGap(stream, cursor))
+		}
+
+		for _, event := range stream.buffer {
+			if event.seq > after {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	subscriber := &streamSubscriber{
+		events: make(chan StreamEvent, len(replay)+env.Env.StreamBufferSize),
+		filter: filter,
+	}
+	for _, event := range replay {
+		if event.Event == WookStreamGap || subscriber.accepts(event.Event) {
+			subscriber.events <- event
+		}
+	}
+	stream.subscribers[subscriber] = struct{}{}
+
+	unsubscribe := func() {
+		stream.mu.Lock()
+		defer stream.mu.Unlock()
+
+		stream.lastActive = time.Now()
+		if _, ok := stream.subscribers[subscriber]; ok {
+			delete(stream.subscribers, subscriber)
+			close(subscriber.events)
+		}
+
+		if len(stream.subscribers) == 0 {
+			time.AfterFunc(streamResumeWindow, func() {
+				s.removeIdleStream(instanceID, stream)
+			})
+		}
+	}
+
+	return subscriber.events, unsubscribe
+}
+
+// lockStream returns the locked stream of the instance, creating it when needed
+func (s *Whatsmiau) lockStream(instanceID string) *eventStream {
+	for {
+		stream, _ := s.streams.LoadOrCompute(instanceID, func() (*eventStream, bool) {
+			return &eventStream{
+				since:       s.streamSeq.Add(1), // older cursors are all from before the stream
+				subscribers: make(map[*streamSubscriber]struct{}),
+			}, false
+		})
+
+		stream.mu.Lock()
+		if !stream.removed {
+			return stream
+		}
+
+		// removed meanwhile, the next load creates it again
+		stream.mu.Unlock()
+	}
+}
+
+// removeIdleStream drops the buffer once nobody streamed the instance for streamResumeWindow
+func (s *Whatsmiau) removeIdleStream(instanceID string, stream *eventStream) {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+
+	if stream.removed || len(stream.subscribers) > 0 || time.Since(stream.lastActive) < streamResumeWindow {
+		return
+	}
+
+	stream.removed = true
+	s.streams.Compute(instanceID, func(current *eventStream, loaded bool) (*eventStream, xsync.ComputeOp) {
+		if current == stream {
+			return nil, xsync.DeleteOp
+		}
+		return current, xsync.CancelOp
+	})
+}
+
+// streamGap tells the subscriber to resume from the oldest buffered event
+func (s *Whatsmiau) streamGap(stream *eventStream, cursor string) StreamEvent {
+	resume := s.streamSeq.Load()
+	if len(stream.buffer) > 0 {
+		resume = stream.buffer[0].seq - 1
+	}
+
+	data, _ := json.Marshal(StreamGap{Cursor: cursor})
+	return StreamEvent{
+		Cursor: s.streamCursor(resume),
+		Event:  WookStreamGap,
+		Data:   data,
+	}
+}
@@ -0,0 +1,166 @@
+package whatsmiau
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+)
+
+func newStreamTest() *Whatsmiau {
+	return &Whatsmiau{
+		streams:     xsync.NewMap[string, *eventStream](),
+		streamEpoch: 1700000000,
+	}
+}
+
+func TestParseStreamCursor(t *testing.T) {
+	s := newStreamTest()
+
+	tests := []struct {
+		cursor string
+		want   uint64
+		ok     bool
+	}{
+		{cursor: "1700000000-0", want: 0, ok: true},
+		{cursor: "1700000000-42", want: 42, ok: true},
+		{cursor: s.streamCursor(7), want: 7, ok: true},
+		{cursor: "1600000000-42", ok: false}, // another process run
+		{cursor: "1700000000--1", ok: false},
+		{cursor: "1700000000-abc", ok: false},
+		{cursor: "1700000000", ok: false},
+		{cursor: "42", ok: false},
+		{cursor: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := s.parseStreamCursor(tt.cursor)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseStreamCursor(%q) = %d, %v, want %d, %v", tt.cursor, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// drain returns the events already queued for the subscriber
+func drain(events <-chan StreamEvent) []StreamEvent {
+	var result []StreamEvent
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return result
+			}
+			result = append(result, event)
+		default:
+			return result
+		}
+	}
+}
+
+func TestSubscribeReplay(t *testing.T) {
+	env.Env.StreamBufferSize = 3
+
+	s := newStreamTest()
+	first, unsubscribe := s.Subscribe("a", "", nil)
+	var received []StreamEvent
+	for range 5 {
+		s.broadcast("a", WookMessagesUpsert, []byte(`{}`))
+		received = append(received, drain(first)...)
+	}
+	unsubscribe()
+
+	if len(received) != 5 {
+		t.Fatalf("received %d events, want 5", len(received))
+	}
+
+	tests := []struct {
+		name    string
+		cursor  string
+		gap     bool
+		replay  []string
+		filters []string
+	}{
+		{name: "no cursor", cursor: "", replay: nil},
+		{name: "buffered cursor", cursor: received[2].Cursor, replay: []string{received[3].Cursor, received[4].Cursor}},
+		{name: "last cursor", cursor: received[4].Cursor, replay: nil},
+		{name: "evicted cursor", cursor: received[0].Cursor, gap: true, replay: []string{received[2].Cursor, received[3].Cursor, received[4].Cursor}},
+		{name: "cursor of the previous run", cursor: "1600000000-4", gap: true, replay: []string{received[2].Cursor, received[3].Cursor, received[4].Cursor}},
+		{name: "gap is not filtered", cursor: "invalid", gap: true, filters: []string{"CONNECTION_UPDATE"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, unsubscribe := s.Subscribe("a", tt.cursor, tt.filters)
+			defer unsubscribe()
+
+			got := drain(events)
+			if tt.gap {
+				if len(got) == 0 || got[0].Event != WookStreamGap {
+					t.Fatalf("first event is not %s: %+v", WookStreamGap, got)
+				}
+
+				var gap StreamGap
+				if err := json.Unmarshal(got[0].Data, &gap); err != nil || gap.Cursor != tt.cursor {
+					t.Errorf("gap data = %s, want the cursor %s", got[0].Data, tt.cursor)
+				}
+
+				// resuming from the gap cursor replays the whole buffer without another gap
+				if after, ok := s.parseStreamCursor(got[0].Cursor); !ok || after != received[1].seq {
+					t.Errorf("gap cursor = %s, want %s", got[0].Cursor, received[1].Cursor)
+				}
+				got = got[1:]
+			}
+
+			var cursors []string
+			for _, event := range got {
+				cursors = append(cursors, event.Cursor)
+			}
+			if len(cursors) != len(tt.replay) {
+				t.Fatalf("replayed %v, want %v", cursors, tt.replay)
+			}
+			for i := range cursors {
+				if cursors[i] != tt.replay[i] {
+					t.Errorf("replayed %v, want %v", cursors, tt.replay)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveIdleStream(t *testing.T) {
+	env.Env.StreamBufferSize = 3
+
+	s := newStreamTest()
+	events, unsubscribe := s.Subscribe("a", "", nil)
+	s.broadcast("a", WookMessagesUpsert, []byte(`{}`))
+	cursor := drain(events)[0].Cursor
+
+	// still streaming, nothing is removed
+	stream, _ := s.streams.Load("a")
+	s.removeIdleStream("a", stream)
+	if _, ok := s.streams.Load("a"); !ok {
+		t.Fatal("stream with subscribers was removed")
+	}
+
+	unsubscribe()
+	stream.mu.Lock()
+	stream.lastActive = stream.lastActive.Add(-streamResumeWindow)
+	stream.mu.Unlock()
+
+	s.removeIdleStream("a", stream)
+	if _, ok := s.streams.Load("a"); ok {
+		t.Fatal("idle stream was not removed")
+	}
+
+	// events emitted while nobody streamed are lost
+	s.broadcast("a", WookMessagesUpsert, []byte(`{}`))
+	events, unsubscribe = s.Subscribe("a", cursor, nil)
+	defer unsubscribe()
+
+	got := drain(events)
+	if len(got) != 1 || got[0].Event != WookStreamGap {
+		t.Fatalf("resuming a removed stream = %+v, want only %s", got, WookStreamGap)
+	}
+}
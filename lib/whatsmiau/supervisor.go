@@ -0,0 +1,313 @@
+package whatsmiau
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+const zombieProbeTimeout = 20 * time.Second
+
+// ConnectionState is what the supervisor saw of the instance connection since the server started
+type ConnectionState struct {
+	LastConnectedAt    time.Time `json:"lastConnectedAt,omitzero"`
+	LastDisconnectedAt time.Time `json:"lastDisconnectedAt,omitzero"`
+	LastEventAt        time.Time `json:"lastEventAt,omitzero"`
+	LastProbeAt        time.Time `json:"lastProbeAt,omitzero"` // last answered probe of a silent connection
+	LastError          string    `json:"lastError,omitempty"`
+	LastErrorAt        time.Time `json:"lastErrorAt,omitzero"`
+	Retries            int       `json:"retries"` // reconnection attempts since the last connection
+	NextRetryAt        time.Time `json:"nextRetryAt,omitzero"`
+	BannedUntil        time.Time `json:"bannedUntil,omitzero"`
+	Replaced           bool      `json:"replaced"` // another client connected with the same session
+	ZombieRestarts     int       `json:"zombieRestarts"`
+}
+
+type connectionSupervisor struct {
+	mu    sync.Mutex
+	state ConnectionState
+	wake  chan struct{}
+	stop  chan struct{}
+}
+
+func (c *connectionSupervisor) update(fn func(state *ConnectionState)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn(&c.state)
+}
+
+func (c *connectionSupervisor) snapshot() ConnectionState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// notify runs a check as soon as possible, without blocking when one is already queued
+func (c *connectionSupervisor) notify() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// supervise keeps a paired instance connected. whatsmeow reconnects once by itself, after that the
+// supervisor retries with a jittered exponential backoff, waits the bans and conflicts out and restarts
+// zombie connections. The first connection waits delay. Calling it again (ex: after a new pairing) only
+// hooks the current client
+func (s *Whatsmiau) supervise(id string, delay time.Duration) {
+	supervisor, loaded := s.supervisors.LoadOrCompute(id, func() (*connectionSupervisor, bool) {
+		return &connectionSupervisor{
+			state: ConnectionState{NextRetryAt: time.Now().Add(delay)},
+			wake:  make(chan struct{}, 1),
+			stop:  make(chan struct{}),
+		}, false
+	})
+
+	if client, ok := s.clients.Load(id); ok {
+		client.AutoReconnectHook = func(err error) bool {
+			supervisor.update(func(state *ConnectionState) {
+				state.LastError = err.Error()
+				state.LastErrorAt = time.Now()
+			})
+			supervisor.notify()
+			return false
+		}
+	}
+
+	if !loaded {
+		go s.runSupervisor(id, supervisor)
+	}
+	supervisor.notify()
+}
+
+// stopSupervisor is called when the instance is logged out or disconnected on purpose, Connect starts it again
+func (s *Whatsmiau) stopSupervisor(id string) {
+	if supervisor, ok := s.supervisors.LoadAndDelete(id); ok {
+		close(supervisor.stop)
+	}
+}
+
+// ConnectionState returns nil when the instance is not supervised (not paired, logged out or disconnected)
+func (s *Whatsmiau) ConnectionState(id string) *ConnectionState {
+	supervisor, ok := s.supervisors.Load(id)
+	if !ok {
+		return nil
+	}
+
+	state := supervisor.snapshot()
+	return &state
+}
+
+func (s *Whatsmiau) runSupervisor(id string, supervisor *connectionSupervisor) {
+	timer := time.NewTimer(env.Env.SupervisorInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-supervisor.stop:
+			zap.L().Debug("stopping connection supervisor", zap.String("id", id))
+			return
+		case <-supervisor.wake:
+		case <-timer.C:
+		}
+
+		timer.Reset(s.checkConnection(id, supervisor))
+	}
+}
+
+// checkConnection reconnects the instance when the retry is due and probes open connections, it returns
+// when to check again
+func (s *Whatsmiau) checkConnection(id string, supervisor *connectionSupervisor) time.Duration {
+	interval := env.Env.SupervisorInterval
+
+	client, ok := s.clients.Load(id)
+	if !ok || !s.hasSomeDevice(client) {
+		return interval
+	}
+
+	// pairing again, the connection observer owns the client
+	if _, ok := s.observerRunning.Load(id); ok {
+		return interval
+	}
+
+	if client.IsConnected() {
+		if client.IsLoggedIn() {
+			s.restartZombie(id, client, supervisor)
+		}
+		return interval
+	}
+
+	now := time.Now()
+	state := supervisor.snapshot()
+	retryAt := state.NextRetryAt
+	if state.BannedUntil.After(retryAt) {
+		retryAt = state.BannedUntil
+	}
+	if wait := retryAt.Sub(now); wait > 0 {
+		return min(wait, interval)
+	}
+
+	err := client.Connect()
+	if errors.Is(err, whatsmeow.ErrAlreadyConnected) {
+		return interval
+	}
+
+	var backoff time.Duration
+	supervisor.update(func(state *ConnectionState) {
+		state.Retries++
+		backoff = reconnectBackoff(state.Retries)
+		state.NextRetryAt = now.Add(backoff)
+		if err != nil {
+			state.LastError = err.Error()
+			state.LastErrorAt = now
+		}
+	})
+
+	zap.L().Info("reconnecting instance", zap.String("id", id), zap.Duration("backoff", backoff), zap.Error(err))
+	return min(backoff, interval)
+}
+
+// restartZombie probes connections without events for ZOMBIE_TIMEOUT and restarts them when the probe
+// fails. The probe is a usync query of the own number (never cached, unlike the devices): a quiet number
+// answers it and is left alone, a connection stuck open doesn't and is restarted, the server delivers the
+// pending messages on the next login
+func (s *Whatsmiau) restartZombie(id string, client *whatsmeow.Client, supervisor *connectionSupervisor) {
+	if env.Env.ZombieTimeout <= 0 {
+		return
+	}
+
+	state := supervisor.snapshot()
+	lastSeen := state.LastEventAt
+	for _, at := range []time.Time{state.LastConnectedAt, state.LastProbeAt} {
+		if at.After(lastSeen) {
+			lastSeen = at
+		}
+	}
+	if time.Since(lastSeen) < env.Env.ZombieTimeout {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), zombieProbeTimeout)
+	defer cancel()
+
+	_, err := client.IsOnWhatsApp(ctx, []string{"+" + client.Store.GetJID().User})
+	if err == nil {
+		supervisor.update(func(state *ConnectionState) {
+			state.LastProbeAt = time.Now()
+		})
+		return
+	}
+
+	zap.L().Warn("zombie connection, restarting", zap.String("id", id), zap.Time("lastSeen", lastSeen), zap.Error(err))
+	supervisor.update(func(state *ConnectionState) {
+		state.ZombieRestarts++
+		state.LastError = fmt.Sprintf("no events for %s and the probe failed: %s", env.Env.ZombieTimeout, err)
+		state.LastErrorAt = time.Now()
+		state.LastDisconnectedAt = time.Now()
+		state.NextRetryAt = time.Time{}
+	})
+
+	// a manual disconnect has no event, the next check connects it again
+	client.Disconnect()
+	s.emitConnectionUpdate(id, &WookConnectionUpdateData{
+		State:        ConnectionStateClose,
+		StatusReason: StatusReasonTimedOut,
+		Reason:       "zombie connection, reconnecting",
+	})
+	supervisor.notify()
+}
+
+// connectJitter spreads the first connection of the instances at boot over RECONNECT_BACKOFF_INITIAL, so
+// a restarted server doesn't log all of them in at once
+func connectJitter() time.Duration {
+	if env.Env.ReconnectBackoffInitial <= 0 {
+		return 0
+	}
+
+	return rand.N(env.Env.ReconnectBackoffInitial)
+}
+
+// trackConnection runs before the handlers, in the whatsmeow goroutine, so the state follows the events order
+func (s *Whatsmiau) trackConnection(id string, evt any) {
+	supervisor, ok := s.supervisors.Load(id)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	wake := false
+	supervisor.update(func(state *ConnectionState) {
+		switch e := evt.(type) {
+		case *events.KeepAliveTimeout:
+			state.LastError = fmt.Sprintf("keepalive timeout (%d errors)", e.ErrorCount)
+			state.LastErrorAt = now
+			return // nothing was received
+		case *events.Connected:
+			state.LastConnectedAt = now
+			state.Retries = 0
+			state.NextRetryAt = time.Time{}
+			state.BannedUntil = time.Time{}
+			state.Replaced = false
+		case *events.Disconnected:
+			// whatsmeow reconnects by itself first
+			state.LastDisconnectedAt = now
+			state.NextRetryAt = now.Add(env.Env.ReconnectBackoffInitial)
+			wake = true
+		case *events.StreamReplaced:
+			// connecting right away would kick the other client out, which would do the same
+			state.LastDisconnectedAt = now
+			state.LastError = "stream replaced"
+			state.LastErrorAt = now
+			state.Replaced = true
+			state.NextRetryAt = now.Add(env.Env.ReconnectBackoffMax)
+			wake = true
+		case *events.TemporaryBan:
+			expire := e.Expire
+			if expire <= 0 {
+				expire = env.Env.ReconnectBackoffMax
+			}
+			state.LastDisconnectedAt = now
+			state.LastError = e.String()
+			state.LastErrorAt = now
+			state.BannedUntil = now.Add(expire)
+			wake = true
+		case *events.ConnectFailure:
+			state.LastError = e.Reason.String()
+			state.LastErrorAt = now
+			wake = true
+		}
+		state.LastEventAt = now
+	})
+
+	if wake {
+		supervisor.notify()
+	}
+}
+
+// reconnectBackoff doubles RECONNECT_BACKOFF_INITIAL up to RECONNECT_BACKOFF_MAX, randomizing the second half
+// so the instances of a server that lost the network don't reconnect all at once
+func reconnectBackoff(retries int) time.Duration {
+	backoff := env.Env.ReconnectBackoffInitial
+	for i := 1; i < retries && backoff < env.Env.ReconnectBackoffMax; i++ {
+		backoff *= 2
+	}
+
+	if backoff > env.Env.ReconnectBackoffMax {
+		backoff = env.Env.ReconnectBackoffMax
+	}
+
+	half := backoff / 2
+	if half <= 0 {
+		return backoff
+	}
+
+	return half + rand.N(half)
+}
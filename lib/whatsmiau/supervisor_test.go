@@ -0,0 +1,46 @@
+package whatsmiau
+
+import (
+	"testing"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+)
+
+func TestReconnectBackoff(t *testing.T) {
+	env.Env.ReconnectBackoffInitial = time.Second
+	env.Env.ReconnectBackoffMax = 8 * time.Second
+
+	tests := []struct {
+		retries int
+		min     time.Duration
+		max     time.Duration // exclusive
+	}{
+		{retries: 0, min: 500 * time.Millisecond, max: time.Second},
+		{retries: 1, min: 500 * time.Millisecond, max: time.Second},
+		{retries: 2, min: time.Second, max: 2 * time.Second},
+		{retries: 3, min: 2 * time.Second, max: 4 * time.Second},
+		{retries: 4, min: 4 * time.Second, max: 8 * time.Second},
+		{retries: 5, min: 4 * time.Second, max: 8 * time.Second}, // capped
+		{retries: 1000, min: 4 * time.Second, max: 8 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for range 100 {
+			if got := reconnectBackoff(tt.retries); got < tt.min || got >= tt.max {
+				t.Fatalf("reconnectBackoff(%d) = %s, want [%s, %s)", tt.retries, got, tt.min, tt.max)
+			}
+		}
+	}
+
+	// a max below the initial wait still caps it
+	env.Env.ReconnectBackoffMax = 300 * time.Millisecond
+	if got := reconnectBackoff(1); got < 150*time.Millisecond || got >= 300*time.Millisecond {
+		t.Errorf("reconnectBackoff(1) = %s, want [150ms, 300ms)", got)
+	}
+
+	env.Env.ReconnectBackoffInitial = 0
+	if got := reconnectBackoff(3); got != 0 {
+		t.Errorf("reconnectBackoff(3) without initial = %s, want 0", got)
+	}
+}
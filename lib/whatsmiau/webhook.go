@@ -0,0 +1,235 @@
+package whatsmiau
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the body when the instance webhook has a secret
+const SignatureHeader = "X-Whatsmiau-Signature"
+
+// errPermanent marks a delivery failure that retrying will not fix (ex: 4xx responses)
+type errPermanent struct {
+	err error
+}
+
+func (e *errPermanent) Error() string {
+	return e.err.Error()
+}
+
+type webhookWorker struct {
+	wake chan struct{}
+	stop chan struct{}
+}
+
+// sleep returns false when the worker was stopped meanwhile
+func (w *webhookWorker) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-w.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// resumeDeliveries starts a worker for every instance that still has queued webhooks (ex: after a restart)
+func (s *Whatsmiau) resumeDeliveries() {
+	ctx, c := context.WithTimeout(context.Background(), 10*time.Second)
+	defer c()
+
+	ids, err := s.webhookQueue.Pending(ctx)
+	if err != nil {
+		zap.L().Error("failed to list pending webhook queues", zap.Error(err))
+		return
+	}
+
+	for _, id := range ids {
+		s.wakeDeliveryWorker(id)
+	}
+}
+
+// wakeDeliveryWorker ensures there is one worker per instance and notifies it about new deliveries
+func (s *Whatsmiau) wakeDeliveryWorker(instanceID string) {
+	worker, loaded := s.deliveryWorkers.LoadOrCompute(instanceID, func() (*webhookWorker, bool) {
+		return &webhookWorker{
+			wake: make(chan struct{}, 1),
+			stop: make(chan struct{}),
+		}, false
+	})
+	if !loaded {
+		go s.deliveryWorker(instanceID, worker)
+	}
+
+	select {
+	case worker.wake <- struct{}{}:
+	default:
+	}
+}
+
+// stopDeliveries stops the worker of a deleted instance and drops its queue and dead-letter list
+func (s *Whatsmiau) stopDeliveries(ctx context.Context, instanceID string) error {
+	if worker, ok := s.deliveryWorkers.LoadAndDelete(instanceID); ok {
+		close(worker.stop)
+	}
+
+	return s.webhookQueue.Delete(ctx, instanceID)
+}
+
+// deliveryWorker delivers the instance queue in order, the head is only removed after success or after
+// being moved to the dead-letter list, so a failing event blocks the next ones until it gives up
+func (s *Whatsmiau) deliveryWorker(instanceID string, worker *webhookWorker) {
+	ctx := context.Background()
+	for {
+		delivery, err := s.webhookQueue.Peek(ctx, instanceID)
+		if err != nil {
+			zap.L().Error("failed to read webhook queue", zap.String("instance", instanceID), zap.Error(err))
+			if !worker.sleep(env.Env.WebhookBackoffInitial) {
+				return
+			}
+			continue
+		}
+
+		if delivery == nil {
+			select {
+			case <-worker.stop:
+				return
+			case <-worker.wake:
+			}
+			continue
+		}
+
+		if wait := time.Until(delivery.NextAttemptAt); wait > 0 && !worker.sleep(wait) {
+			return
+		}
+
+		status, err := s.deliver(ctx, delivery)
+		if err == nil {
+			if err := s.webhookQueue.Ack(ctx, instanceID); err != nil {
+				zap.L().Error("failed to ack webhook delivery", zap.String("instance", instanceID), zap.Error(err))
+			}
+			continue
+		}
+
+		delivery.Attempts++
+		delivery.LastStatus = status
+		delivery.LastError = err.Error()
+
+		_, permanent := err.(*errPermanent)
+		if permanent || delivery.Attempts >= env.Env.WebhookMaxAttempts {
+			zap.L().Error("webhook delivery failed, moving to dead-letter",
+				zap.String("instance", instanceID),
+				zap.String("event", delivery.Event),
+				zap.String("url", delivery.Url),
+				zap.Int("attempts", delivery.Attempts),
+				zap.Error(err),
+			)
+			delivery.FailedAt = time.Now()
+			if err := s.webhookQueue.Fail(ctx, delivery); err != nil {
+				zap.L().Error("failed to dead-letter webhook delivery", zap.String("instance", instanceID), zap.Error(err))
+			}
+			continue
+		}
+
+		delivery.NextAttemptAt = time.Now().Add(webhookBackoff(delivery.Attempts))
+		zap.L().Warn("webhook delivery failed, retrying",
+			zap.String("instance", instanceID),
+			zap.String("event", delivery.Event),
+			zap.String("url", delivery.Url),
+			zap.Int("attempts", delivery.Attempts),
+			zap.Time("nextAttemptAt", delivery.NextAttemptAt),
+			zap.Error(err),
+		)
+		if err := s.webhookQueue.Retry(ctx, delivery); err != nil {
+			zap.L().Error("failed to reschedule webhook delivery", zap.String("instance", instanceID), zap.Error(err))
+		}
+	}
+}
+
+// deliver posts the event once and returns the response status
+func (s *Whatsmiau) deliver(ctx context.Context, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, &errPermanent{err}
+	}
+
+	// a repository error is retried like a network one, the headers and secret are needed to deliver
+	instance, err := s.lookupInstanceCached(delivery.InstanceID)
+	if err != nil {
+		return 0, err
+	}
+
+	// without the instance the delivery would go out without its headers and signature
+	if instance == nil || instance.Webhook == nil {
+		return 0, &errPermanent{errors.New("instance or its webhook was removed")}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range instance.Webhook.Headers {
+		req.Header.Set(k, v)
+	}
+
+	if len(instance.Webhook.Secret) > 0 {
+		req.Header.Set(SignatureHeader, signPayload(instance.Webhook.Secret, delivery.Payload))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+
+	res, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("webhook responded %d: %s", resp.StatusCode, string(res))
+
+	// client errors won't change on retry, except timeouts and rate limits
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return resp.StatusCode, &errPermanent{err}
+	}
+
+	return resp.StatusCode, err
+}
+
+// signPayload returns "sha256=<hex hmac>" of the body, receivers should compare it with the raw request body
+func signPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookBackoff(attempts int) time.Duration {
+	backoff := env.Env.WebhookBackoffInitial
+	for i := 1; i < attempts && backoff < env.Env.WebhookBackoffMax; i++ {
+		backoff *= 2
+	}
+
+	if backoff > env.Env.WebhookBackoffMax {
+		return env.Env.WebhookBackoffMax
+	}
+
+	return backoff
+}
+
+func (s *Whatsmiau) ListFailedWebhooks(ctx context.Context, id string, offset, limit int64) ([]models.WebhookDelivery, error) {
+	return s.webhookQueue.ListFailed(ctx, id, offset, limit)
+}
@@ -0,0 +1,120 @@
+package whatsmiau
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+func TestWebhookBackoff(t *testing.T) {
+	env.Env.WebhookBackoffInitial = time.Second
+	env.Env.WebhookBackoffMax = 10 * time.Second
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: time.Second},
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 3, want: 4 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+		{attempts: 5, want: 10 * time.Second},
+		{attempts: 100, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := webhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestSignPayload(t *testing.T) {
+	tests := []struct {
+		secret  string
+		payload string
+		want    string
+	}{
+		{secret: "", payload: "", want: "sha256=b613679a0814d9ec772f95d778c35fc5ff1697c493715653c6c712144292c5ad"},
+		{secret: "key", payload: "The quick brown fox jumps over the lazy dog", want: "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+	}
+
+	for _, tt := range tests {
+		if got := signPayload(tt.secret, []byte(tt.payload)); got != tt.want {
+			t.Errorf("signPayload(%q, %q) = %s, want %s", tt.secret, tt.payload, got, tt.want)
+		}
+	}
+}
+
+// fakeInstances only lists the instances it has
+type fakeInstances struct {
+	interfaces.InstanceRepository
+	instances map[string]models.Instance
+}
+
+func (f *fakeInstances) List(_ context.Context, id string) ([]models.Instance, error) {
+	instance, ok := f.instances[id]
+	if !ok {
+		return nil, nil
+	}
+	return []models.Instance{instance}, nil
+}
+
+func TestDeliver(t *testing.T) {
+	var received *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+	}))
+	defer server.Close()
+
+	s := &Whatsmiau{
+		repo: &fakeInstances{instances: map[string]models.Instance{
+			"signed":     {ID: "signed", Webhook: &models.InstanceWebhook{Url: server.URL, Headers: map[string]string{"Authorization": "Bearer a"}, Secret: "secret"}},
+			"no-webhook": {ID: "no-webhook"},
+		}},
+		instanceCache: xsync.NewMap[string, models.Instance](),
+		httpClient:    server.Client(),
+	}
+
+	tests := []struct {
+		instance  string
+		permanent bool
+	}{
+		{instance: "signed"},
+		{instance: "no-webhook", permanent: true},
+		{instance: "removed", permanent: true},
+	}
+
+	payload := []byte(`{"event":"messages.upsert"}`)
+	for _, tt := range tests {
+		t.Run(tt.instance, func(t *testing.T) {
+			received = nil
+			_, err := s.deliver(context.Background(), &models.WebhookDelivery{InstanceID: tt.instance, Url: server.URL, Payload: payload})
+
+			if tt.permanent {
+				if _, ok := err.(*errPermanent); !ok {
+					t.Errorf("deliver() error = %v, want a permanent error", err)
+				}
+				if received != nil {
+					t.Error("deliver() posted the event without the instance headers")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("deliver() error: %v", err)
+			}
+			if received.Header.Get("Authorization") != "Bearer a" || received.Header.Get(SignatureHeader) != signPayload("secret", payload) {
+				t.Errorf("deliver() headers = %v", received.Header)
+			}
+		})
+	}
+}
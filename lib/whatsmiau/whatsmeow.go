@@ -1,17 +1,25 @@
 package whatsmiau
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/puzpuzpuz/xsync/v4"
 	"github.com/verbeux-ai/whatsmiau/env"
 	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/lib/broker/rabbitmq"
+	"github.com/verbeux-ai/whatsmiau/lib/broker/sqs"
 	"github.com/verbeux-ai/whatsmiau/lib/storage/gcs"
 	"github.com/verbeux-ai/whatsmiau/models"
+	"github.com/verbeux-ai/whatsmiau/repositories/chats"
 	"github.com/verbeux-ai/whatsmiau/repositories/instances"
+	"github.com/verbeux-ai/whatsmiau/repositories/messages"
+	"github.com/verbeux-ai/whatsmiau/repositories/polls"
+	"github.com/verbeux-ai/whatsmiau/repositories/webhooks"
 	"github.com/verbeux-ai/whatsmiau/services"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/store/sqlstore"
@@ -27,14 +35,30 @@ type Whatsmiau struct {
 	logger           waLog.Logger
 	repo             interfaces.InstanceRepository
 	qrCache          *xsync.Map[string, string]
+	pairingCodes     *xsync.Map[string, pairingCode]
 	observerRunning  *xsync.Map[string, bool]
 	instanceCache    *xsync.Map[string, models.Instance]
 	lockConnection   *xsync.Map[string, *sync.Mutex]
+	supervisors      *xsync.Map[string, *connectionSupervisor]
 	emitter          chan emitter
+	webhookQueue     interfaces.WebhookQueue
+	deliveryWorkers  *xsync.Map[string, *webhookWorker]
+	rabbitmq         *brokerQueue
+	sqs              *brokerQueue
+	streams          *xsync.Map[string, *eventStream]
+	streamEpoch      int64
+	streamSeq        atomic.Uint64 // shared by the instances, cursors keep growing when a stream is recreated
+	pollOptions      interfaces.PollRepository
+	messageCache     *xsync.Map[string, *messageCache]
+	messageStore     interfaces.MessageRepository
+	chatStore        interfaces.ChatRepository
 	httpClient       *http.Client
+	mediaClient      *http.Client
+	previewClient    *http.Client
 	fileStorage      interfaces.Storage
 	handlerSemaphore chan struct{}
-	ChatwootService  *chatwoot.Service  // ← CORRIGIDO (era *ChatwootService)
+	mediaSemaphore   chan struct{}
+	ChatwootService  *chatwoot.Service // ← CORRIGIDO (era *ChatwootService)
 }
 
 var instance *Whatsmiau
@@ -94,10 +118,7 @@ func LoadMiau(ctx context.Context, container *sqlstore.Container) {
 				Username: instanceFound.ProxyUsername,
 				Password: instanceFound.ProxyPassword,
 			})
-			clients.Store(instanceFound.ID, client)
-			if err := client.Connect(); err != nil {
-				zap.L().Error("failed to connect connected device", zap.Error(err), zap.String("jid", client.Store.ID.String()))
-			}
+			clients.Store(instanceFound.ID, client) // connected by the supervisor
 			continue
 		}
 
@@ -119,29 +140,83 @@ func LoadMiau(ctx context.Context, container *sqlstore.Container) {
 		}
 	}
 
+	var rabbitQueue *brokerQueue
+	if env.Env.RabbitMQURL != "" {
+		rabbitQueue = newBrokerQueue("rabbitmq", rabbitmq.New(env.Env.RabbitMQURL, env.Env.RabbitMQExchange))
+	}
+
+	var sqsQueue *brokerQueue
+	if env.Env.SQSEnabled {
+		sqsPublisher, err := sqs.New(ctx, sqs.Config{
+			Region:      env.Env.SQSRegion,
+			Endpoint:    env.Env.SQSEndpoint,
+			QueuePrefix: env.Env.SQSQueuePrefix,
+			QueueMode:   sqs.QueueMode(env.Env.SQSQueueMode),
+			BufferSize:  env.Env.EmitterBufferSize,
+		})
+		if err != nil {
+			zap.L().Panic("failed to create SQS publisher", zap.Error(err))
+		}
+		sqsQueue = newBrokerQueue("sqs", sqsPublisher)
+	}
+
+	var messageStore interfaces.MessageRepository
+	var chatStore interfaces.ChatRepository
+	switch env.Env.MessageStore {
+	case "sql":
+		messageStore, err = messages.NewSQL(ctx, services.SQLDB())
+		if err != nil {
+			zap.L().Panic("failed to create message store", zap.Error(err))
+		}
+		chatStore, err = chats.NewSQL(ctx, services.SQLDB())
+		if err != nil {
+			zap.L().Panic("failed to create chat store", zap.Error(err))
+		}
+	case "redis":
+		messageStore = messages.NewRedis(services.Redis(), env.Env.MessageStoreTTL)
+		chatStore = chats.NewRedis(services.Redis(), env.Env.MessageStoreTTL)
+	}
+
 	instance = &Whatsmiau{
 		clients:         clients,
 		container:       container,
 		logger:          clientLog,
 		repo:            repo,
 		qrCache:         xsync.NewMap[string, string](),
+		pairingCodes:    xsync.NewMap[string, pairingCode](),
 		instanceCache:   xsync.NewMap[string, models.Instance](),
 		observerRunning: xsync.NewMap[string, bool](),
 		lockConnection:  xsync.NewMap[string, *sync.Mutex](),
+		supervisors:     xsync.NewMap[string, *connectionSupervisor](),
 		emitter:         make(chan emitter, env.Env.EmitterBufferSize),
+		webhookQueue:    webhooks.NewRedis(services.Redis(), env.Env.WebhookFailedLimit),
+		deliveryWorkers: xsync.NewMap[string, *webhookWorker](),
+		rabbitmq:        rabbitQueue,
+		sqs:             sqsQueue,
+		streams:         xsync.NewMap[string, *eventStream](),
+		streamEpoch:     time.Now().Unix(),
+		pollOptions:     polls.NewRedis(services.Redis(), env.Env.PollOptionsTTL),
+		messageCache:    xsync.NewMap[string, *messageCache](),
+		messageStore:    messageStore,
+		chatStore:       chatStore,
 		httpClient: &http.Client{
 			Timeout: time.Second * 30,
 		},
+		mediaClient:      &http.Client{}, // bounded by MEDIA_DOWNLOAD_TIMEOUT
+		previewClient:    newPreviewClient(),
 		fileStorage:      storage,
 		handlerSemaphore: make(chan struct{}, env.Env.HandlerSemaphoreSize),
-		ChatwootService:  chatwoot.NewService(),  // ← CORRIGIDO (sem config global)
+		mediaSemaphore:   make(chan struct{}, env.Env.MediaConcurrency),
+		ChatwootService:  chatwoot.NewService(), // ← CORRIGIDO (sem config global)
 	}
 
 	go instance.startEmitter()
+	instance.resumeDeliveries()
 
 	clients.Range(func(id string, client *whatsmeow.Client) bool {
 		zap.L().Info("stating event handler", zap.String("jid", client.Store.ID.String()))
 		client.AddEventHandler(instance.Handle(id))
+		instance.supervise(id, connectJitter())
 		return true
 	})
 
@@ -153,6 +228,7 @@ func (s *Whatsmiau) Connect(ctx context.Context, id string) (string, error) {
 		return "", err
 	}
 	if client == nil {
+		s.supervise(id, 0) // resumes after a Disconnect
 		return "", nil
 	}
 
@@ -168,6 +244,51 @@ func (s *Whatsmiau) Connect(ctx context.Context, id string) (string, error) {
 	return qrCode, nil
 }
 
+// pairingClientDisplayName must be "Browser (OS)" with a browser and OS known by WhatsApp
+const pairingClientDisplayName = "Chrome (Linux)"
+
+type pairingCode struct {
+	Number string
+	Code   string
+}
+
+// ConnectWithPhone connects like Connect and also returns the 8 characters code that pairs the number
+// without scanning the QR code (Linked devices > Link with phone number instead). Both are empty when
+// the instance is already connected. When WhatsApp refuses the code, the QR code is still returned
+// without it, only an invalid number is an error
+func (s *Whatsmiau) ConnectWithPhone(ctx context.Context, id, number string) (string, string, error) {
+	qrCode, err := s.Connect(ctx, id)
+	if err != nil || qrCode == "" {
+		return "", "", err
+	}
+
+	// a new code invalidates the previous one, keep it while the same observer is running
+	if cached, ok := s.pairingCodes.Load(id); ok && cached.Number == number {
+		return qrCode, cached.Code, nil
+	}
+
+	client, ok := s.clients.Load(id)
+	if !ok {
+		zap.L().Warn("no client to pair the phone", zap.String("id", id))
+		return qrCode, "", nil
+	}
+
+	code, err := client.PairPhone(ctx, number, true, whatsmeow.PairClientChrome, pairingClientDisplayName)
+	if errors.Is(err, whatsmeow.ErrPhoneNumberTooShort) || errors.Is(err, whatsmeow.ErrPhoneNumberIsNotInternational) {
+		return "", "", err
+	}
+	if err != nil {
+		zap.L().Error("failed to get the pairing code", zap.String("id", id), zap.Error(err))
+		return qrCode, "", nil
+	}
+
+	code = strings.ReplaceAll(code, "-", "")
+	s.pairingCodes.Store(id, pairingCode{Number: number, Code: code})
+	s.emitQRCodeUpdated(id, qrCode)
+
+	return qrCode, code, nil
+}
+
 func (s *Whatsmiau) generateClient(ctx context.Context, id string) (*whatsmeow.Client, error) {
 	lock, ok := s.lockConnection.Load(id)
 	if !ok {
@@ -247,6 +368,7 @@ func (s *Whatsmiau) observeConnection(client *whatsmeow.Client, id string) {
 		zap.L().Debug("stopping observer connection", zap.String("id", id))
 		s.observerRunning.Delete(id)
 		s.qrCache.Delete(id)
+		s.pairingCodes.Delete(id)
 		s.lockConnection.Delete(id)
 	}()
 
@@ -270,17 +392,28 @@ func (s *Whatsmiau) observeConnection(client *whatsmeow.Client, id string) {
 			Username: instanceFound.ProxyUsername,
 			Password: instanceFound.ProxyPassword,
 		})
+		configHistorySync(client, instanceFound.SyncFullHistory)
 	}
-	
+
 	if err := client.Connect(); err != nil {
 		zap.L().Error("failed to connect connected device", zap.Error(err))
 		s.clients.Delete(id)
 		if err := s.deleteDeviceIfExists(context.TODO(), client); err != nil {
 			zap.L().Error("failed to cleanup device after Connect error", zap.String("id", id), zap.Error(err))
 		}
+		s.emitConnectionUpdate(id, &WookConnectionUpdateData{
+			State:        ConnectionStateClose,
+			StatusReason: StatusReasonFailed,
+			Reason:       err.Error(),
+		})
 		return
 	}
 
+	s.emitConnectionUpdate(id, &WookConnectionUpdateData{
+		State:        ConnectionStateConnecting,
+		StatusReason: StatusReasonOpen,
+	})
+
 	zap.L().Debug("waiting for QR channel event", zap.String("id", id))
 	for {
 		select {
@@ -290,6 +423,11 @@ func (s *Whatsmiau) observeConnection(client *whatsmeow.Client, id string) {
 				zap.L().Error("failed to hard logout", zap.String("id", id), zap.Error(err))
 			}
 			s.clients.Delete(id)
+			s.emitConnectionUpdate(id, &WookConnectionUpdateData{
+				State:        ConnectionStateClose,
+				StatusReason: StatusReasonTimedOut,
+				Reason:       "qr code expired without being read",
+			})
 			return
 		case evt, ok := <-qrChan:
 			if !ok || evt.Event == "error" || evt.Event == "timeout" {
@@ -300,6 +438,7 @@ func (s *Whatsmiau) observeConnection(client *whatsmeow.Client, id string) {
 			zap.L().Debug("received QR channel event", zap.String("id", id), zap.Any("evt", evt))
 			if evt.Event == "code" {
 				s.qrCache.Store(id, evt.Code)
+				s.emitQRCodeUpdated(id, evt.Code)
 				continue
 			}
 
@@ -313,8 +452,10 @@ func (s *Whatsmiau) observeConnection(client *whatsmeow.Client, id string) {
 				zap.L().Info("device connected successfully", zap.String("id", id))
 				client.RemoveEventHandlers()
 				client.AddEventHandler(s.Handle(id))
-				if _, err := s.repo.Update(context.Background(), id, &models.Instance{
-					RemoteJID: client.Store.ID.String(),
+				s.supervise(id, 0)
+				remoteJID := client.Store.ID.String()
+				if _, err := s.repo.Update(context.Background(), id, &models.InstanceUpdate{
+					RemoteJID: &remoteJID,
 				}); err != nil {
 					zap.L().Error("failed to update instance after login", zap.Error(err))
 				}
@@ -398,8 +539,20 @@ func (s *Whatsmiau) Logout(ctx context.Context, id string) error {
 		return nil
 	}
 
+	s.stopSupervisor(id)
 	s.clients.Delete(id)
-	return s.deleteDeviceIfExists(ctx, client)
+	s.messageCache.Delete(id)
+	if err := s.deleteDeviceIfExists(ctx, client); err != nil {
+		return err
+	}
+
+	s.emitConnectionUpdate(id, &WookConnectionUpdateData{
+		State:        ConnectionStateClose,
+		StatusReason: StatusReasonLoggedOut,
+		Reason:       "logged out by the api",
+	})
+	s.emitLogout(id, StatusReasonLoggedOut, "logged out by the api")
+	return nil
 }
 
 func (s *Whatsmiau) Disconnect(id string) error {
@@ -409,8 +562,10 @@ func (s *Whatsmiau) Disconnect(id string) error {
 		return nil
 	}
 
+	s.stopSupervisor(id)
 	client.Disconnect()
 	s.qrCache.Delete(id)
+	s.pairingCodes.Delete(id)
 	return nil
 }
 
@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// Chat is a conversation of an instance kept by the chat store
+type Chat struct {
+	InstanceID  string       `json:"instanceId"`
+	RemoteJid   string       `json:"remoteJid"`
+	RemoteLid   string       `json:"remoteLid,omitempty"`
+	Name        string       `json:"name,omitempty"`
+	LastMessage *ChatMessage `json:"lastMessage,omitempty"`
+	UnreadCount int          `json:"unreadCount"`
+	Archived    bool         `json:"archived"`
+	Pinned      bool         `json:"pinned"`
+	MuteEndTime int64        `json:"muteEndTime,omitempty"` // unix milliseconds, -1 is muted forever
+	Timestamp   time.Time    `json:"timestamp"`             // last activity
+}
+
+// ChatMessage is the preview of the last message of a chat
+type ChatMessage struct {
+	ID          string    `json:"id"`
+	FromMe      bool      `json:"fromMe"`
+	MessageType string    `json:"messageType,omitempty"`
+	Text        string    `json:"text,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// ChatUpdate changes only the set fields of a chat, which is created when missing
+type ChatUpdate struct {
+	RemoteLid   string
+	Name        string
+	LastMessage *ChatMessage // ignored when older than the current one
+	Timestamp   time.Time    // ignored when older than the current one
+	UnreadCount *int
+	AddUnread   int
+	Archived    *bool
+	Pinned      *bool
+	MuteEndTime *int64
+}
+
+// Apply merges the update into the chat
+func (c *Chat) Apply(update *ChatUpdate) {
+	if update.RemoteLid != "" {
+		c.RemoteLid = update.RemoteLid
+	}
+	if update.Name != "" {
+		c.Name = update.Name
+	}
+
+	if update.LastMessage != nil && (c.LastMessage == nil || !update.LastMessage.Timestamp.Before(c.LastMessage.Timestamp)) {
+		c.LastMessage = update.LastMessage
+		if update.LastMessage.Timestamp.After(c.Timestamp) {
+			c.Timestamp = update.LastMessage.Timestamp
+		}
+	}
+	if update.Timestamp.After(c.Timestamp) {
+		c.Timestamp = update.Timestamp
+	}
+
+	if update.UnreadCount != nil {
+		c.UnreadCount = *update.UnreadCount
+	}
+	c.UnreadCount += update.AddUnread
+
+	if update.Archived != nil {
+		c.Archived = *update.Archived
+	}
+	if update.Pinned != nil {
+		c.Pinned = *update.Pinned
+	}
+	if update.MuteEndTime != nil {
+		c.MuteEndTime = *update.MuteEndTime
+	}
+}
+
+// ChatFilter selects the stored chats of an instance, zero values are ignored
+type ChatFilter struct {
+	InstanceID string
+	RemoteJid  string // matches the jid or the lid of the chat
+	Archived   *bool
+	Offset     int
+	Limit      int
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChatApply(t *testing.T) {
+	yes, no := true, false
+	zero, five := 0, 5
+	forever := int64(-1)
+	older, now, newer := time.Unix(1700000000, 0), time.Unix(1700000100, 0), time.Unix(1700000200, 0)
+	last := &ChatMessage{ID: "a", Text: "hi", Timestamp: now}
+
+	tests := []struct {
+		name   string
+		stored Chat
+		update ChatUpdate
+		want   Chat
+	}{
+		{
+			name:   "empty update keeps everything",
+			stored: Chat{RemoteJid: "j", RemoteLid: "l", Name: "n", LastMessage: last, UnreadCount: 2, Archived: true, Pinned: true, MuteEndTime: -1, Timestamp: now},
+			update: ChatUpdate{},
+			want:   Chat{RemoteJid: "j", RemoteLid: "l", Name: "n", LastMessage: last, UnreadCount: 2, Archived: true, Pinned: true, MuteEndTime: -1, Timestamp: now},
+		},
+		{
+			name:   "lid and name",
+			stored: Chat{RemoteJid: "j", Name: "old"},
+			update: ChatUpdate{RemoteLid: "l", Name: "new"},
+			want:   Chat{RemoteJid: "j", RemoteLid: "l", Name: "new"},
+		},
+		{
+			name:   "newer message moves the timestamp",
+			stored: Chat{LastMessage: last, Timestamp: now},
+			update: ChatUpdate{LastMessage: &ChatMessage{ID: "b", Timestamp: newer}},
+			want:   Chat{LastMessage: &ChatMessage{ID: "b", Timestamp: newer}, Timestamp: newer},
+		},
+		{
+			name:   "older message is ignored",
+			stored: Chat{LastMessage: last, Timestamp: now},
+			update: ChatUpdate{LastMessage: &ChatMessage{ID: "b", Timestamp: older}, Timestamp: older},
+			want:   Chat{LastMessage: last, Timestamp: now},
+		},
+		{
+			name:   "message of the same second replaces it",
+			stored: Chat{LastMessage: last, Timestamp: now},
+			update: ChatUpdate{LastMessage: &ChatMessage{ID: "b", Timestamp: now}},
+			want:   Chat{LastMessage: &ChatMessage{ID: "b", Timestamp: now}, Timestamp: now},
+		},
+		{
+			name:   "first message",
+			stored: Chat{},
+			update: ChatUpdate{LastMessage: last, AddUnread: 1},
+			want:   Chat{LastMessage: last, UnreadCount: 1, Timestamp: now},
+		},
+		{
+			name:   "added unread",
+			stored: Chat{UnreadCount: 2},
+			update: ChatUpdate{AddUnread: 1},
+			want:   Chat{UnreadCount: 3},
+		},
+		{
+			name:   "read",
+			stored: Chat{UnreadCount: 2},
+			update: ChatUpdate{UnreadCount: &zero},
+			want:   Chat{UnreadCount: 0},
+		},
+		{
+			name:   "unread count is set before adding",
+			stored: Chat{UnreadCount: 2},
+			update: ChatUpdate{UnreadCount: &five, AddUnread: 1},
+			want:   Chat{UnreadCount: 6},
+		},
+		{
+			name:   "archive, pin and mute",
+			stored: Chat{Archived: false, Pinned: true},
+			update: ChatUpdate{Archived: &yes, Pinned: &no, MuteEndTime: &forever},
+			want:   Chat{Archived: true, Pinned: false, MuteEndTime: -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := tt.stored
+			chat.Apply(&tt.update)
+			if !reflect.DeepEqual(chat, tt.want) {
+				t.Errorf("Apply() = %+v, want %+v", chat, tt.want)
+			}
+		})
+	}
+}
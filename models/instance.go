@@ -1,5 +1,7 @@
 package models
 
+import "maps"
+
 type Instance struct {
 	ID        string `json:"id"`
 	RemoteJID string `json:"remoteJid,omitempty"`
@@ -69,6 +71,98 @@ type InstanceWebhook struct {
 	Base64   *bool             `json:"base64,omitempty"`   // Ponteiro para permitir nil
 	Headers  map[string]string `json:"headers,omitempty"`
 	Events   []string          `json:"events,omitempty"`
+	Secret   string            `json:"secret,omitempty"` // signs the body with HMAC-SHA256 when set
+}
+
+// InstanceUpdate carries the fields to change, nil fields keep the stored value
+type InstanceUpdate struct {
+	RemoteJID *string
+	Webhook   *InstanceWebhookUpdate
+	RabbitMQ  *InstanceBroker
+	SQS       *InstanceBroker
+
+	RejectCall      *bool
+	MsgCall         *string
+	GroupsIgnore    *bool
+	AlwaysOnline    *bool
+	ReadMessages    *bool
+	ReadStatus      *bool
+	SyncFullHistory *bool
+}
+
+type InstanceWebhookUpdate struct {
+	Url      *string
+	ByEvents *bool
+	Base64   *bool
+	Headers  map[string]string // merged into the stored headers
+	Events   []string          // an empty list keeps the stored events
+	Secret   *string
+}
+
+// Apply merges the update into the instance
+func (i *Instance) Apply(update *InstanceUpdate) {
+	if update.RemoteJID != nil {
+		i.RemoteJID = *update.RemoteJID
+	}
+
+	if webhook := update.Webhook; webhook != nil {
+		if i.Webhook == nil {
+			i.Webhook = &InstanceWebhook{}
+		}
+		if webhook.Url != nil {
+			i.Webhook.Url = *webhook.Url
+		}
+		if webhook.ByEvents != nil {
+			i.Webhook.ByEvents = webhook.ByEvents
+		}
+		if webhook.Base64 != nil {
+			i.Webhook.Base64 = webhook.Base64
+		}
+		if webhook.Headers != nil {
+			// a new map, the stored one may be shared with cached copies of the instance
+			headers := maps.Clone(i.Webhook.Headers)
+			if headers == nil {
+				headers = map[string]string{}
+			}
+			maps.Copy(headers, webhook.Headers)
+			i.Webhook.Headers = headers
+		}
+		if len(webhook.Events) > 0 {
+			i.Webhook.Events = webhook.Events
+		}
+		if webhook.Secret != nil {
+			i.Webhook.Secret = *webhook.Secret
+		}
+	}
+
+	if update.RabbitMQ != nil {
+		i.RabbitMQ = update.RabbitMQ
+	}
+	if update.SQS != nil {
+		i.SQS = update.SQS
+	}
+
+	if update.RejectCall != nil {
+		i.RejectCall = *update.RejectCall
+	}
+	if update.MsgCall != nil {
+		i.MsgCall = *update.MsgCall
+	}
+	if update.GroupsIgnore != nil {
+		i.GroupsIgnore = *update.GroupsIgnore
+	}
+	if update.AlwaysOnline != nil {
+		i.AlwaysOnline = *update.AlwaysOnline
+	}
+	if update.ReadMessages != nil {
+		i.ReadMessages = *update.ReadMessages
+	}
+	if update.ReadStatus != nil {
+		i.ReadStatus = *update.ReadStatus
+	}
+	if update.SyncFullHistory != nil {
+		i.SyncFullHistory = *update.SyncFullHistory
+	}
 }
 
 type InstanceBroker struct {
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInstanceApply(t *testing.T) {
+	yes, no := true, false
+	empty, jid, url, msg := "", "5511999999999@s.whatsapp.net", "https://example.com/hook", "busy"
+
+	tests := []struct {
+		name   string
+		stored Instance
+		update InstanceUpdate
+		want   Instance
+	}{
+		{
+			name:   "empty update keeps everything",
+			stored: Instance{ID: "a", RejectCall: true, MsgCall: "busy", ReadMessages: true, Webhook: &InstanceWebhook{Url: url, Secret: "s"}},
+			update: InstanceUpdate{},
+			want:   Instance{ID: "a", RejectCall: true, MsgCall: "busy", ReadMessages: true, Webhook: &InstanceWebhook{Url: url, Secret: "s"}},
+		},
+		{
+			name:   "remote jid only keeps the settings",
+			stored: Instance{ID: "a", GroupsIgnore: true, AlwaysOnline: true},
+			update: InstanceUpdate{RemoteJID: &jid},
+			want:   Instance{ID: "a", RemoteJID: jid, GroupsIgnore: true, AlwaysOnline: true},
+		},
+		{
+			name:   "settings can be turned off",
+			stored: Instance{ID: "a", RejectCall: true, MsgCall: "busy", ReadStatus: true, SyncFullHistory: true},
+			update: InstanceUpdate{RejectCall: &no, MsgCall: &empty, ReadStatus: &no, SyncFullHistory: &no},
+			want:   Instance{ID: "a"},
+		},
+		{
+			name:   "settings can be turned on",
+			stored: Instance{ID: "a"},
+			update: InstanceUpdate{RejectCall: &yes, MsgCall: &msg, GroupsIgnore: &yes},
+			want:   Instance{ID: "a", RejectCall: true, MsgCall: "busy", GroupsIgnore: true},
+		},
+		{
+			name:   "webhook is created when missing",
+			stored: Instance{ID: "a"},
+			update: InstanceUpdate{Webhook: &InstanceWebhookUpdate{Url: &url, ByEvents: &yes}},
+			want:   Instance{ID: "a", Webhook: &InstanceWebhook{Url: url, ByEvents: &yes}},
+		},
+		{
+			name:   "webhook headers are merged and events replaced",
+			stored: Instance{ID: "a", Webhook: &InstanceWebhook{Url: url, Headers: map[string]string{"a": "1", "b": "1"}, Events: []string{"A"}}},
+			update: InstanceUpdate{Webhook: &InstanceWebhookUpdate{Headers: map[string]string{"b": "2", "c": "3"}, Events: []string{"B"}}},
+			want:   Instance{ID: "a", Webhook: &InstanceWebhook{Url: url, Headers: map[string]string{"a": "1", "b": "2", "c": "3"}, Events: []string{"B"}}},
+		},
+		{
+			name:   "webhook headers are created when missing",
+			stored: Instance{ID: "a", Webhook: &InstanceWebhook{Url: url}},
+			update: InstanceUpdate{Webhook: &InstanceWebhookUpdate{Headers: map[string]string{"a": "1"}}},
+			want:   Instance{ID: "a", Webhook: &InstanceWebhook{Url: url, Headers: map[string]string{"a": "1"}}},
+		},
+		{
+			name:   "empty webhook events are ignored",
+			stored: Instance{ID: "a", Webhook: &InstanceWebhook{Url: url, Events: []string{"A"}}},
+			update: InstanceUpdate{Webhook: &InstanceWebhookUpdate{Headers: map[string]string{}, Events: []string{}}},
+			want:   Instance{ID: "a", Webhook: &InstanceWebhook{Url: url, Headers: map[string]string{}, Events: []string{"A"}}},
+		},
+		{
+			name:   "brokers are replaced",
+			stored: Instance{ID: "a", RabbitMQ: &InstanceBroker{Enabled: true}},
+			update: InstanceUpdate{RabbitMQ: &InstanceBroker{}, SQS: &InstanceBroker{Enabled: true, Events: []string{"A"}}},
+			want:   Instance{ID: "a", RabbitMQ: &InstanceBroker{}, SQS: &InstanceBroker{Enabled: true, Events: []string{"A"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.stored
+			got.Apply(&tt.update)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
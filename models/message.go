@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Message is a sent or received message kept by the message store
+type Message struct {
+	ID          string          `json:"id"`
+	InstanceID  string          `json:"instanceId"`
+	RemoteJid   string          `json:"remoteJid"`
+	RemoteLid   string          `json:"remoteLid,omitempty"`
+	FromMe      bool            `json:"fromMe"`
+	MessageType string          `json:"messageType,omitempty"`
+	Status      string          `json:"status,omitempty"`
+	Timestamp   time.Time       `json:"timestamp"`
+	Data        json.RawMessage `json:"data"` // the messages.upsert data
+}
+
+// MessageFilter selects the stored messages of an instance, zero values are ignored
+type MessageFilter struct {
+	InstanceID string
+	ID         string
+	RemoteJid  string // matches the jid or the lid of the chat
+	FromMe     *bool
+	After      time.Time // inclusive
+	Before     time.Time // inclusive
+	Offset     int
+	Limit      int
+}
+
+// MessageStatusOrder are the statuses of a stored message in the order they happen, a status only
+// replaces one of the same or a lower rank so late receipts don't move the message back
+var MessageStatusOrder = []string{"PENDING", "SERVER_ACK", "DELIVERY_ACK", "READ", "PLAYED", "EDITED", "DELETED"}
+
+// MessageStatusRank returns the position of the status in MessageStatusOrder starting at 1, unknown
+// statuses are 0
+func MessageStatusRank(status string) int {
+	for i, s := range MessageStatusOrder {
+		if s == status {
+			return i + 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookDelivery is a single webhook event waiting (or failed) to be delivered
+type WebhookDelivery struct {
+	ID            string          `json:"id"`
+	InstanceID    string          `json:"instanceId"`
+	Event         string          `json:"event"`
+	Url           string          `json:"url"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int             `json:"attempts"`
+	LastStatus    int             `json:"lastStatus,omitempty"`
+	LastError     string          `json:"lastError,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt,omitempty"`
+	FailedAt      time.Time       `json:"failedAt,omitempty"`
+}
@@ -0,0 +1,7 @@
+package chats
+
+import "errors"
+
+var (
+	ErrInstanceIDEmpty = errors.New("chat InstanceID cannot be empty")
+)
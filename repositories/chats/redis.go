@@ -0,0 +1,179 @@
+package chats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+// These verify if RedisChat follows chats interface pattern
+var _ interfaces.ChatRepository = (*RedisChat)(nil)
+
+const (
+	redisBatchSize = 500
+	// redisTxRetries is how many times a transaction runs again when the chat changed meanwhile
+	redisTxRetries = 10
+)
+
+// RedisChat keeps each chat for ttl after its last update, indexed by a sorted set of the instance
+// (score is the last activity)
+type RedisChat struct {
+	db  *redis.Client
+	ttl time.Duration
+}
+
+func (s *RedisChat) key(instanceID, remoteJid string) string {
+	return fmt.Sprintf("chat_%s_%s", instanceID, remoteJid)
+}
+
+func (s *RedisChat) indexKey(instanceID string) string {
+	return fmt.Sprintf("chats_%s", instanceID)
+}
+
+func NewRedis(client *redis.Client, ttl time.Duration) *RedisChat {
+	return &RedisChat{
+		db:  client,
+		ttl: ttl,
+	}
+}
+
+func (s *RedisChat) Update(ctx context.Context, instanceID, remoteJid string, update *models.ChatUpdate) error {
+	if instanceID == "" {
+		return ErrInstanceIDEmpty
+	}
+
+	key := s.key(instanceID, remoteJid)
+	index := s.indexKey(instanceID)
+	expired := strconv.FormatInt(time.Now().Add(-s.ttl).Unix(), 10)
+
+	// the events of a chat arrive concurrently, a change meanwhile runs the update again
+	return s.watch(ctx, key, func(tx *redis.Tx) error {
+		chat := &models.Chat{InstanceID: instanceID, RemoteJid: remoteJid}
+
+		raw, err := tx.Get(ctx, key).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		if err == nil {
+			if err := json.Unmarshal([]byte(raw), chat); err != nil {
+				return err
+			}
+		}
+
+		chat.Apply(update)
+
+		data, err := json.Marshal(chat)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, s.ttl)
+			pipe.ZAdd(ctx, index, &redis.Z{Score: float64(chat.Timestamp.Unix()), Member: remoteJid})
+			pipe.ZRemRangeByScore(ctx, index, "-inf", "("+expired)
+			pipe.Expire(ctx, index, s.ttl)
+			return nil
+		})
+		return err
+	})
+}
+
+// watch runs fn in a transaction, again when key is changed by someone else before it commits
+func (s *RedisChat) watch(ctx context.Context, key string, fn func(tx *redis.Tx) error) error {
+	for range redisTxRetries {
+		err := s.db.Watch(ctx, fn, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+
+	return redis.TxFailedErr
+}
+
+func (s *RedisChat) Find(ctx context.Context, filter *models.ChatFilter) ([]models.Chat, int, error) {
+	if filter.InstanceID == "" {
+		return nil, 0, ErrInstanceIDEmpty
+	}
+
+	jids, err := s.db.ZRevRange(ctx, s.indexKey(filter.InstanceID), 0, -1).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var matches []models.Chat
+	for start := 0; start < len(jids); start += redisBatchSize {
+		chats, err := s.load(ctx, filter.InstanceID, jids[start:min(start+redisBatchSize, len(jids))])
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, chat := range chats {
+			if filter.RemoteJid != "" && chat.RemoteJid != filter.RemoteJid && chat.RemoteLid != filter.RemoteJid {
+				continue
+			}
+			if filter.Archived != nil && chat.Archived != *filter.Archived {
+				continue
+			}
+			matches = append(matches, chat)
+		}
+	}
+
+	// the index is already ordered by the last activity
+	slices.SortStableFunc(matches, func(a, b models.Chat) int {
+		switch {
+		case a.Pinned == b.Pinned:
+			return 0
+		case a.Pinned:
+			return -1
+		default:
+			return 1
+		}
+	})
+
+	if filter.Offset >= len(matches) {
+		return nil, len(matches), nil
+	}
+
+	return matches[filter.Offset:min(filter.Offset+filter.Limit, len(matches))], len(matches), nil
+}
+
+// load returns the chats of jids in order, expired ones are skipped
+func (s *RedisChat) load(ctx context.Context, instanceID string, jids []string) ([]models.Chat, error) {
+	if len(jids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(jids))
+	for _, jid := range jids {
+		keys = append(keys, s.key(instanceID, jid))
+	}
+
+	rawList, err := s.db.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Chat, 0, len(rawList))
+	for _, raw := range rawList {
+		data, ok := raw.(string)
+		if !ok {
+			continue
+		}
+
+		var chat models.Chat
+		if err := json.Unmarshal([]byte(data), &chat); err != nil {
+			continue
+		}
+		result = append(result, chat)
+	}
+
+	return result, nil
+}
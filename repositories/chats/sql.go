@@ -0,0 +1,178 @@
+package chats
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+// These verify if SQLChat follows chats interface pattern
+var _ interfaces.ChatRepository = (*SQLChat)(nil)
+
+// sqlite and postgres accept the same schema and $n placeholders
+const sqlSchema = `
+CREATE TABLE IF NOT EXISTS whatsmiau_chats (
+	instance_id     TEXT    NOT NULL,
+	remote_jid      TEXT    NOT NULL,
+	remote_lid      TEXT    NOT NULL DEFAULT '',
+	name            TEXT    NOT NULL DEFAULT '',
+	last_message    TEXT    NOT NULL DEFAULT '',
+	last_message_at BIGINT  NOT NULL DEFAULT 0,
+	unread_count    INTEGER NOT NULL DEFAULT 0,
+	archived        BOOLEAN NOT NULL DEFAULT FALSE,
+	pinned          BOOLEAN NOT NULL DEFAULT FALSE,
+	mute_end_time   BIGINT  NOT NULL DEFAULT 0,
+	timestamp       BIGINT  NOT NULL DEFAULT 0,
+	PRIMARY KEY (instance_id, remote_jid)
+);
+CREATE INDEX IF NOT EXISTS whatsmiau_chats_timestamp ON whatsmiau_chats (instance_id, pinned, timestamp);
+`
+
+const sqlColumns = "instance_id, remote_jid, remote_lid, name, last_message, unread_count, archived, pinned, mute_end_time, timestamp"
+
+type SQLChat struct {
+	db *sql.DB
+}
+
+// NewSQL creates the chats table when missing
+func NewSQL(ctx context.Context, db *sql.DB) (*SQLChat, error) {
+	for _, statement := range strings.Split(sqlSchema, ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return nil, fmt.Errorf("failed to create chats table: %w", err)
+		}
+	}
+
+	return &SQLChat{
+		db: db,
+	}, nil
+}
+
+func (s *SQLChat) Update(ctx context.Context, instanceID, remoteJid string, update *models.ChatUpdate) error {
+	if instanceID == "" {
+		return ErrInstanceIDEmpty
+	}
+
+	// the values of a new chat, a stored one is merged like Chat.Apply in a single statement since the
+	// events of a chat arrive concurrently
+	chat := &models.Chat{InstanceID: instanceID, RemoteJid: remoteJid}
+	chat.Apply(update)
+
+	var (
+		lastMessage   []byte
+		lastMessageAt int64
+		err           error
+	)
+	if chat.LastMessage != nil {
+		if lastMessage, err = json.Marshal(chat.LastMessage); err != nil {
+			return err
+		}
+		lastMessageAt = chat.LastMessage.Timestamp.Unix()
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO whatsmiau_chats (`+sqlColumns+`, last_message_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (instance_id, remote_jid) DO UPDATE SET
+			remote_lid = COALESCE(NULLIF(excluded.remote_lid, ''), whatsmiau_chats.remote_lid),
+			name = COALESCE(NULLIF(excluded.name, ''), whatsmiau_chats.name),
+			last_message = CASE WHEN excluded.last_message <> '' AND excluded.last_message_at >= whatsmiau_chats.last_message_at
+				THEN excluded.last_message ELSE whatsmiau_chats.last_message END,
+			last_message_at = CASE WHEN excluded.last_message <> '' AND excluded.last_message_at >= whatsmiau_chats.last_message_at
+				THEN excluded.last_message_at ELSE whatsmiau_chats.last_message_at END,
+			unread_count = COALESCE($12, whatsmiau_chats.unread_count) + $13,
+			archived = COALESCE($14, whatsmiau_chats.archived),
+			pinned = COALESCE($15, whatsmiau_chats.pinned),
+			mute_end_time = COALESCE($16, whatsmiau_chats.mute_end_time),
+			timestamp = CASE WHEN excluded.timestamp > whatsmiau_chats.timestamp
+				THEN excluded.timestamp ELSE whatsmiau_chats.timestamp END`,
+		chat.InstanceID, chat.RemoteJid, chat.RemoteLid, chat.Name, string(lastMessage), chat.UnreadCount,
+		chat.Archived, chat.Pinned, chat.MuteEndTime, chat.Timestamp.Unix(), lastMessageAt,
+		update.UnreadCount, update.AddUnread, update.Archived, update.Pinned, update.MuteEndTime,
+	)
+
+	return err
+}
+
+func (s *SQLChat) Find(ctx context.Context, filter *models.ChatFilter) ([]models.Chat, int, error) {
+	if filter.InstanceID == "" {
+		return nil, 0, ErrInstanceIDEmpty
+	}
+
+	conditions := []string{"instance_id = $1"}
+	args := []any{filter.InstanceID}
+	where := func(condition string, values ...any) {
+		for _, value := range values {
+			args = append(args, value)
+			condition = strings.Replace(condition, "?", fmt.Sprintf("$%d", len(args)), 1)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if filter.RemoteJid != "" {
+		where("(remote_jid = ? OR remote_lid = ?)", filter.RemoteJid, filter.RemoteJid)
+	}
+	if filter.Archived != nil {
+		where("archived = ?", *filter.Archived)
+	}
+
+	whereClause := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM whatsmiau_chats WHERE "+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s FROM whatsmiau_chats WHERE %s
+		ORDER BY pinned DESC, timestamp DESC, remote_jid
+		LIMIT $%d OFFSET $%d`, sqlColumns, whereClause, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var result []models.Chat
+	for rows.Next() {
+		chat, err := scanChat(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, *chat)
+	}
+
+	return result, total, rows.Err()
+}
+
+func scanChat(row interface{ Scan(dest ...any) error }) (*models.Chat, error) {
+	var (
+		chat        models.Chat
+		lastMessage string
+		timestamp   int64
+	)
+
+	if err := row.Scan(&chat.InstanceID, &chat.RemoteJid, &chat.RemoteLid, &chat.Name, &lastMessage, &chat.UnreadCount,
+		&chat.Archived, &chat.Pinned, &chat.MuteEndTime, &timestamp); err != nil {
+		return nil, err
+	}
+
+	chat.Timestamp = time.Unix(timestamp, 0)
+	if lastMessage != "" {
+		chat.LastMessage = &models.ChatMessage{}
+		if err := json.Unmarshal([]byte(lastMessage), chat.LastMessage); err != nil {
+			return nil, err
+		}
+	}
+
+	return &chat, nil
+}
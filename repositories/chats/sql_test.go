@@ -0,0 +1,104 @@
+package chats
+
+import (
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+func newSQLTest(t *testing.T) *SQLChat {
+	// a file, the connections of a memory database don't share it
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "chats.db")+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	repo, err := NewSQL(context.Background(), db)
+	if err != nil {
+		t.Fatalf("NewSQL() error: %v", err)
+	}
+
+	return repo
+}
+
+// TestSQLUpdate checks the upsert merges like Chat.Apply
+func TestSQLUpdate(t *testing.T) {
+	yes, no := true, false
+	zero := 0
+	forever := int64(-1)
+	older, now, newer := time.Unix(1700000000, 0), time.Unix(1700000100, 0), time.Unix(1700000200, 0)
+
+	updates := [][]models.ChatUpdate{
+		{{Name: "n", LastMessage: &models.ChatMessage{ID: "a", Text: "hi", Timestamp: now}, AddUnread: 1}},
+		{
+			{RemoteLid: "l", LastMessage: &models.ChatMessage{ID: "a", Timestamp: now}, AddUnread: 1},
+			{LastMessage: &models.ChatMessage{ID: "b", Timestamp: older}, AddUnread: 1},
+			{Name: "", Archived: &yes, Pinned: &yes, MuteEndTime: &forever},
+		},
+		{
+			{LastMessage: &models.ChatMessage{ID: "a", Timestamp: now}, AddUnread: 2},
+			{UnreadCount: &zero, Timestamp: newer},
+			{Pinned: &no, AddUnread: 1},
+		},
+		{
+			{Archived: &yes},
+			{LastMessage: &models.ChatMessage{ID: "a", FromMe: true, Timestamp: now}},
+		},
+	}
+
+	ctx := context.Background()
+	for i, sequence := range updates {
+		repo := newSQLTest(t)
+		want := models.Chat{InstanceID: "i", RemoteJid: "j"}
+		for _, update := range sequence {
+			if err := repo.Update(ctx, "i", "j", &update); err != nil {
+				t.Fatalf("Update() error: %v", err)
+			}
+			want.Apply(&update)
+		}
+
+		chats, _, err := repo.Find(ctx, &models.ChatFilter{InstanceID: "i", Limit: 1})
+		if err != nil || len(chats) != 1 {
+			t.Fatalf("Find() = %v, %v", chats, err)
+		}
+		// compared as json, the times are read back in another location
+		got, _ := json.Marshal(chats[0])
+		expected, _ := json.Marshal(want)
+		if string(got) != string(expected) {
+			t.Errorf("updates %d: stored %s, want %s", i, got, expected)
+		}
+	}
+}
+
+func TestSQLUpdateConcurrent(t *testing.T) {
+	repo := newSQLTest(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := repo.Update(ctx, "i", "j", &models.ChatUpdate{AddUnread: 1}); err != nil {
+				t.Errorf("Update() error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	chats, _, err := repo.Find(ctx, &models.ChatFilter{InstanceID: "i", Limit: 1})
+	if err != nil || len(chats) != 1 {
+		t.Fatalf("Find() = %v, %v", chats, err)
+	}
+	if chats[0].UnreadCount != 20 {
+		t.Errorf("unread count = %d, want 20", chats[0].UnreadCount)
+	}
+}
@@ -52,7 +52,7 @@ func (s *RedisInstance) Create(ctx context.Context, instance *models.Instance) e
 	return s.db.Set(ctx, s.key(instance.ID), data, redis.KeepTTL).Err()
 }
 
-func (s *RedisInstance) Update(ctx context.Context, id string, toUpdate *models.Instance) (*models.Instance, error) {
+func (s *RedisInstance) Update(ctx context.Context, id string, toUpdate *models.InstanceUpdate) (*models.Instance, error) {
 	if id == "" {
 		return nil, ErrInstanceIDEmpty
 	}
@@ -67,29 +67,7 @@ func (s *RedisInstance) Update(ctx context.Context, id string, toUpdate *models.
 	}
 
 	oldInstance := result[0]
-	if len(toUpdate.RemoteJID) > 0 {
-		oldInstance.RemoteJID = toUpdate.RemoteJID
-	}
-	if toUpdate.Webhook.Url != "" {
-		oldInstance.Webhook.Url = toUpdate.Webhook.Url
-	}
-	if toUpdate.Webhook.ByEvents != nil {
-		oldInstance.Webhook.ByEvents = toUpdate.Webhook.ByEvents
-	}
-	if toUpdate.Webhook.Base64 != nil {
-		oldInstance.Webhook.Base64 = toUpdate.Webhook.Base64
-	}
-	if toUpdate.Webhook.Headers != nil {
-		if oldInstance.Webhook.Headers == nil {
-			oldInstance.Webhook.Headers = map[string]string{}
-		}
-		for k, v := range toUpdate.Webhook.Headers {
-			oldInstance.Webhook.Headers[k] = v
-		}
-	}
-	if toUpdate.Webhook.Events != nil && len(toUpdate.Webhook.Events) > 0 {
-		oldInstance.Webhook.Events = toUpdate.Webhook.Events
-	}
+	oldInstance.Apply(toUpdate)
 
 	data, err := json.Marshal(oldInstance)
 	if err != nil {
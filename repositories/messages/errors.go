@@ -0,0 +1,7 @@
+package messages
+
+import "errors"
+
+var (
+	ErrInstanceIDEmpty = errors.New("message InstanceID cannot be empty")
+)
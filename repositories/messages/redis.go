@@ -0,0 +1,247 @@
+package messages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+// These verify if RedisMessage follows messages interface pattern
+var _ interfaces.MessageRepository = (*RedisMessage)(nil)
+
+const (
+	redisBatchSize = 500
+	// redisTxRetries is how many times a transaction runs again when the message changed meanwhile
+	redisTxRetries = 10
+)
+
+// RedisMessage keeps each message for ttl, indexed by sorted sets (score is the timestamp) of the
+// instance and of each chat
+type RedisMessage struct {
+	db  *redis.Client
+	ttl time.Duration
+}
+
+func (s *RedisMessage) key(instanceID, id string) string {
+	return fmt.Sprintf("message_%s_%s", instanceID, id)
+}
+
+func (s *RedisMessage) indexKey(instanceID string) string {
+	return fmt.Sprintf("messages_%s", instanceID)
+}
+
+func (s *RedisMessage) chatIndexKey(instanceID, remoteJid string) string {
+	return fmt.Sprintf("messages_%s_%s", instanceID, remoteJid)
+}
+
+func NewRedis(client *redis.Client, ttl time.Duration) *RedisMessage {
+	return &RedisMessage{
+		db:  client,
+		ttl: ttl,
+	}
+}
+
+func (s *RedisMessage) Save(ctx context.Context, message *models.Message) error {
+	if message.InstanceID == "" {
+		return ErrInstanceIDEmpty
+	}
+
+	indexes := []string{s.indexKey(message.InstanceID), s.chatIndexKey(message.InstanceID, message.RemoteJid)}
+	if message.RemoteLid != "" && message.RemoteLid != message.RemoteJid {
+		indexes = append(indexes, s.chatIndexKey(message.InstanceID, message.RemoteLid))
+	}
+
+	key := s.key(message.InstanceID, message.ID)
+	expired := strconv.FormatInt(time.Now().Add(-s.ttl).Unix(), 10)
+	return s.watch(ctx, key, func(tx *redis.Tx) error {
+		stored, err := s.get(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		// saving again (ex: edits) keeps the status received meanwhile
+		if stored != nil {
+			message.Status = stored.Status
+		}
+
+		data, err := json.Marshal(message)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, s.ttl)
+			for _, index := range indexes {
+				pipe.ZAdd(ctx, index, &redis.Z{Score: float64(message.Timestamp.Unix()), Member: message.ID})
+				pipe.ZRemRangeByScore(ctx, index, "-inf", "("+expired)
+				pipe.Expire(ctx, index, s.ttl)
+			}
+			return nil
+		})
+		return err
+	})
+}
+
+func (s *RedisMessage) UpdateStatus(ctx context.Context, instanceID, id, status string) error {
+	if instanceID == "" {
+		return ErrInstanceIDEmpty
+	}
+
+	key := s.key(instanceID, id)
+	return s.watch(ctx, key, func(tx *redis.Tx) error {
+		message, err := s.get(ctx, tx, key)
+		if err != nil || message == nil {
+			return err
+		}
+
+		// late receipts (ex: delivered after read) don't move the message back
+		if models.MessageStatusRank(status) < models.MessageStatusRank(message.Status) {
+			return nil
+		}
+
+		message.Status = status
+		data, err := json.Marshal(message)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, redis.KeepTTL)
+			return nil
+		})
+		return err
+	})
+}
+
+func (s *RedisMessage) Find(ctx context.Context, filter *models.MessageFilter) ([]models.Message, int, error) {
+	if filter.InstanceID == "" {
+		return nil, 0, ErrInstanceIDEmpty
+	}
+
+	var ids []string
+	if filter.ID != "" {
+		ids = []string{filter.ID}
+	} else {
+		index := s.indexKey(filter.InstanceID)
+		if filter.RemoteJid != "" {
+			index = s.chatIndexKey(filter.InstanceID, filter.RemoteJid)
+		}
+
+		scoreRange := &redis.ZRangeBy{Min: "-inf", Max: "+inf"}
+		if !filter.After.IsZero() {
+			scoreRange.Min = strconv.FormatInt(filter.After.Unix(), 10)
+		}
+		if !filter.Before.IsZero() {
+			scoreRange.Max = strconv.FormatInt(filter.Before.Unix(), 10)
+		}
+
+		var err error
+		ids, err = s.db.ZRevRangeByScore(ctx, index, scoreRange).Result()
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// without fromMe (or the exact id) the index already has the page, otherwise every match is loaded
+	if filter.FromMe == nil && filter.ID == "" {
+		messages, err := s.load(ctx, filter.InstanceID, page(ids, filter.Offset, filter.Limit))
+		return messages, len(ids), err
+	}
+
+	var matches []models.Message
+	for start := 0; start < len(ids); start += redisBatchSize {
+		messages, err := s.load(ctx, filter.InstanceID, ids[start:min(start+redisBatchSize, len(ids))])
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, message := range messages {
+			if filter.FromMe != nil && message.FromMe != *filter.FromMe {
+				continue
+			}
+			if filter.RemoteJid != "" && message.RemoteJid != filter.RemoteJid && message.RemoteLid != filter.RemoteJid {
+				continue
+			}
+			matches = append(matches, message)
+		}
+	}
+
+	return page(matches, filter.Offset, filter.Limit), len(matches), nil
+}
+
+// watch runs fn in a transaction, again when key is changed by someone else before it commits
+func (s *RedisMessage) watch(ctx context.Context, key string, fn func(tx *redis.Tx) error) error {
+	for range redisTxRetries {
+		err := s.db.Watch(ctx, fn, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+
+	return redis.TxFailedErr
+}
+
+func (s *RedisMessage) get(ctx context.Context, db redis.Cmdable, key string) (*models.Message, error) {
+	raw, err := db.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var message models.Message
+	if err := json.Unmarshal([]byte(raw), &message); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// load returns the messages of ids in order, expired ones are skipped
+func (s *RedisMessage) load(ctx context.Context, instanceID string, ids []string) ([]models.Message, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, s.key(instanceID, id))
+	}
+
+	rawList, err := s.db.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Message, 0, len(rawList))
+	for _, raw := range rawList {
+		data, ok := raw.(string)
+		if !ok {
+			continue
+		}
+
+		var message models.Message
+		if err := json.Unmarshal([]byte(data), &message); err != nil {
+			continue
+		}
+		result = append(result, message)
+	}
+
+	return result, nil
+}
+
+func page[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+
+	return items[offset:min(offset+limit, len(items))]
+}
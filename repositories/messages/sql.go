@@ -0,0 +1,177 @@
+package messages
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+// These verify if SQLMessage follows messages interface pattern
+var _ interfaces.MessageRepository = (*SQLMessage)(nil)
+
+// sqlite and postgres accept the same schema and $n placeholders
+const sqlSchema = `
+CREATE TABLE IF NOT EXISTS whatsmiau_messages (
+	instance_id  TEXT    NOT NULL,
+	id           TEXT    NOT NULL,
+	remote_jid   TEXT    NOT NULL,
+	remote_lid   TEXT    NOT NULL DEFAULT '',
+	from_me      BOOLEAN NOT NULL,
+	message_type TEXT    NOT NULL DEFAULT '',
+	status       TEXT    NOT NULL DEFAULT '',
+	timestamp    BIGINT  NOT NULL,
+	data         TEXT    NOT NULL,
+	PRIMARY KEY (instance_id, id)
+);
+CREATE INDEX IF NOT EXISTS whatsmiau_messages_timestamp ON whatsmiau_messages (instance_id, timestamp);
+CREATE INDEX IF NOT EXISTS whatsmiau_messages_chat ON whatsmiau_messages (instance_id, remote_jid, timestamp);
+`
+
+// sqlStatusRank is models.MessageStatusRank of the stored status
+var sqlStatusRank = func() string {
+	var rank strings.Builder
+	rank.WriteString("CASE status")
+	for i, status := range models.MessageStatusOrder {
+		fmt.Fprintf(&rank, " WHEN '%s' THEN %d", status, i+1)
+	}
+	rank.WriteString(" ELSE 0 END")
+	return rank.String()
+}()
+
+type SQLMessage struct {
+	db *sql.DB
+}
+
+// NewSQL creates the messages table when missing
+func NewSQL(ctx context.Context, db *sql.DB) (*SQLMessage, error) {
+	for _, statement := range strings.Split(sqlSchema, ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return nil, fmt.Errorf("failed to create messages table: %w", err)
+		}
+	}
+
+	return &SQLMessage{
+		db: db,
+	}, nil
+}
+
+func (s *SQLMessage) Save(ctx context.Context, message *models.Message) error {
+	if message.InstanceID == "" {
+		return ErrInstanceIDEmpty
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO whatsmiau_messages (instance_id, id, remote_jid, remote_lid, from_me, message_type, status, timestamp, data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (instance_id, id) DO UPDATE SET
+			remote_jid = excluded.remote_jid,
+			remote_lid = excluded.remote_lid,
+			message_type = excluded.message_type,
+			data = excluded.data`,
+		message.InstanceID, message.ID, message.RemoteJid, message.RemoteLid, message.FromMe,
+		message.MessageType, message.Status, message.Timestamp.Unix(), string(message.Data),
+	)
+
+	return err
+}
+
+func (s *SQLMessage) UpdateStatus(ctx context.Context, instanceID, id, status string) error {
+	if instanceID == "" {
+		return ErrInstanceIDEmpty
+	}
+
+	_, err := s.db.ExecContext(ctx, `UPDATE whatsmiau_messages SET status = $1 WHERE instance_id = $2 AND id = $3 AND `+sqlStatusRank+` <= $4`,
+		status, instanceID, id, models.MessageStatusRank(status))
+
+	return err
+}
+
+func (s *SQLMessage) Find(ctx context.Context, filter *models.MessageFilter) ([]models.Message, int, error) {
+	if filter.InstanceID == "" {
+		return nil, 0, ErrInstanceIDEmpty
+	}
+
+	var where sqlWhere
+	where.add("instance_id = ?", filter.InstanceID)
+	if filter.ID != "" {
+		where.add("id = ?", filter.ID)
+	}
+	if filter.RemoteJid != "" {
+		where.add("(remote_jid = ? OR remote_lid = ?)", filter.RemoteJid, filter.RemoteJid)
+	}
+	if filter.FromMe != nil {
+		where.add("from_me = ?", *filter.FromMe)
+	}
+	if !filter.After.IsZero() {
+		where.add("timestamp >= ?", filter.After.Unix())
+	}
+	if !filter.Before.IsZero() {
+		where.add("timestamp <= ?", filter.Before.Unix())
+	}
+
+	whereClause, args := where.String(), where.args
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM whatsmiau_messages WHERE "+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, instance_id, remote_jid, remote_lid, from_me, message_type, status, timestamp, data
+		FROM whatsmiau_messages WHERE %s
+		ORDER BY timestamp DESC, id DESC
+		LIMIT $%d OFFSET $%d`, whereClause, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var result []models.Message
+	for rows.Next() {
+		var (
+			message   models.Message
+			timestamp int64
+			data      string
+		)
+
+		if err := rows.Scan(&message.ID, &message.InstanceID, &message.RemoteJid, &message.RemoteLid, &message.FromMe,
+			&message.MessageType, &message.Status, &timestamp, &data); err != nil {
+			return nil, 0, err
+		}
+
+		message.Timestamp = time.Unix(timestamp, 0)
+		message.Data = []byte(data)
+		result = append(result, message)
+	}
+
+	return result, total, rows.Err()
+}
+
+// sqlWhere builds a WHERE clause, numbering the ? placeholders of each condition as $n
+type sqlWhere struct {
+	conditions []string
+	args       []any
+}
+
+// add appends the condition, each ? is replaced by the placeholder of the next value
+func (w *sqlWhere) add(condition string, values ...any) {
+	for _, value := range values {
+		w.args = append(w.args, value)
+		condition = strings.Replace(condition, "?", fmt.Sprintf("$%d", len(w.args)), 1)
+	}
+	w.conditions = append(w.conditions, condition)
+}
+
+func (w *sqlWhere) String() string {
+	return strings.Join(w.conditions, " AND ")
+}
@@ -0,0 +1,126 @@
+package messages
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+func TestSQLWhere(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions [][]any // the condition followed by its values
+		want       string
+		args       []any
+	}{
+		{
+			name:       "single",
+			conditions: [][]any{{"instance_id = ?", "a"}},
+			want:       "instance_id = $1",
+			args:       []any{"a"},
+		},
+		{
+			name:       "numbered after the previous values",
+			conditions: [][]any{{"instance_id = ?", "a"}, {"(remote_jid = ? OR remote_lid = ?)", "b", "b"}, {"from_me = ?", true}},
+			want:       "instance_id = $1 AND (remote_jid = $2 OR remote_lid = $3) AND from_me = $4",
+			args:       []any{"a", "b", "b", true},
+		},
+		{
+			name:       "without values",
+			conditions: [][]any{{"instance_id = ?", "a"}, {"status <> ''"}},
+			want:       "instance_id = $1 AND status <> ''",
+			args:       []any{"a"},
+		},
+		{
+			name:       "more than nine values",
+			conditions: [][]any{{"id IN (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, {"from_me = ?", false}},
+			want:       "id IN ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) AND from_me = $11",
+			args:       []any{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var where sqlWhere
+			for _, condition := range tt.conditions {
+				where.add(condition[0].(string), condition[1:]...)
+			}
+
+			if got := where.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+			if !reflect.DeepEqual(where.args, tt.args) {
+				t.Errorf("args = %v, want %v", where.args, tt.args)
+			}
+		})
+	}
+}
+
+func newSQLTest(t *testing.T) *SQLMessage {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1) // each connection has its own memory database
+	t.Cleanup(func() { _ = db.Close() })
+
+	repo, err := NewSQL(context.Background(), db)
+	if err != nil {
+		t.Fatalf("NewSQL() error: %v", err)
+	}
+
+	return repo
+}
+
+func TestSQLUpdateStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   string
+		statuses []string
+		want     string
+	}{
+		{name: "forward", stored: "SERVER_ACK", statuses: []string{"DELIVERY_ACK", "READ"}, want: "READ"},
+		{name: "late delivery after read", stored: "SERVER_ACK", statuses: []string{"READ", "DELIVERY_ACK"}, want: "READ"},
+		{name: "played after read", stored: "DELIVERY_ACK", statuses: []string{"READ", "PLAYED"}, want: "PLAYED"},
+		{name: "edited after read", stored: "SERVER_ACK", statuses: []string{"READ", "EDITED", "READ"}, want: "EDITED"},
+		{name: "deleted is final", stored: "SERVER_ACK", statuses: []string{"DELETED", "EDITED", "READ"}, want: "DELETED"},
+		{name: "unknown stored status", stored: "sent", statuses: []string{"DELIVERY_ACK"}, want: "DELIVERY_ACK"},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newSQLTest(t)
+			if err := repo.Save(ctx, &models.Message{
+				ID:         "m",
+				InstanceID: "a",
+				RemoteJid:  "5511999999999@s.whatsapp.net",
+				FromMe:     true,
+				Status:     tt.stored,
+				Timestamp:  time.Unix(1700000000, 0),
+				Data:       []byte(`{}`),
+			}); err != nil {
+				t.Fatalf("Save() error: %v", err)
+			}
+
+			for _, status := range tt.statuses {
+				if err := repo.UpdateStatus(ctx, "a", "m", status); err != nil {
+					t.Fatalf("UpdateStatus(%s) error: %v", status, err)
+				}
+			}
+
+			messages, _, err := repo.Find(ctx, &models.MessageFilter{InstanceID: "a", ID: "m", Limit: 1})
+			if err != nil || len(messages) != 1 {
+				t.Fatalf("Find() = %v, %v", messages, err)
+			}
+			if messages[0].Status != tt.want {
+				t.Errorf("status = %s, want %s", messages[0].Status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+package polls
+
+import "errors"
+
+var (
+	ErrInstanceIDEmpty = errors.New("poll InstanceID cannot be empty")
+)
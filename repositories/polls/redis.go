@@ -0,0 +1,66 @@
+package polls
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"golang.org/x/net/context"
+)
+
+// These verify if RedisPoll follows polls interface pattern
+var _ interfaces.PollRepository = (*RedisPoll)(nil)
+
+// RedisPoll keeps the options of each poll for ttl after it was sent or received
+type RedisPoll struct {
+	db  *redis.Client
+	ttl time.Duration
+}
+
+func (s *RedisPoll) key(instanceID, pollID string) string {
+	return fmt.Sprintf("poll_%s_%s", instanceID, pollID)
+}
+
+func NewRedis(client *redis.Client, ttl time.Duration) *RedisPoll {
+	return &RedisPoll{
+		db:  client,
+		ttl: ttl,
+	}
+}
+
+func (s *RedisPoll) Store(ctx context.Context, instanceID, pollID string, options []string) error {
+	if instanceID == "" {
+		return ErrInstanceIDEmpty
+	}
+
+	data, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Set(ctx, s.key(instanceID, pollID), data, s.ttl).Err()
+}
+
+func (s *RedisPoll) Options(ctx context.Context, instanceID, pollID string) ([]string, error) {
+	if instanceID == "" {
+		return nil, ErrInstanceIDEmpty
+	}
+
+	raw, err := s.db.Get(ctx, s.key(instanceID, pollID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var options []string
+	if err := json.Unmarshal(raw, &options); err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
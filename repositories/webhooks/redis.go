@@ -0,0 +1,196 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+// These verify if RedisWebhook follows webhook queue interface pattern
+var _ interfaces.WebhookQueue = (*RedisWebhook)(nil)
+
+var ErrInstanceIDEmpty = errors.New("webhook InstanceID cannot be empty")
+
+const queuePrefix = "webhook_queue_"
+
+type RedisWebhook struct {
+	db          *redis.Client
+	failedLimit int64
+}
+
+func (s *RedisWebhook) queueKey(id string) string {
+	return fmt.Sprintf("%s%s", queuePrefix, id)
+}
+
+func (s *RedisWebhook) failedKey(id string) string {
+	return fmt.Sprintf("webhook_failed_%s", id)
+}
+
+// NewRedis creates the queue, failedLimit caps how many dead-letter entries are kept per instance
+func NewRedis(client *redis.Client, failedLimit int64) *RedisWebhook {
+	return &RedisWebhook{
+		db:          client,
+		failedLimit: failedLimit,
+	}
+}
+
+func (s *RedisWebhook) Enqueue(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if delivery.InstanceID == "" {
+		return ErrInstanceIDEmpty
+	}
+
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+
+	return s.db.RPush(ctx, s.queueKey(delivery.InstanceID), data).Err()
+}
+
+func (s *RedisWebhook) Peek(ctx context.Context, instanceID string) (*models.WebhookDelivery, error) {
+	for {
+		raw, err := s.db.LIndex(ctx, s.queueKey(instanceID), 0).Result()
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var delivery models.WebhookDelivery
+		if err := json.Unmarshal([]byte(raw), &delivery); err != nil {
+			if err := s.failCorrupt(ctx, instanceID, raw, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		return &delivery, nil
+	}
+}
+
+// failCorrupt moves an entry that can't be decoded to the dead-letter list, keeping it as a string payload
+func (s *RedisWebhook) failCorrupt(ctx context.Context, instanceID, raw string, decodeErr error) error {
+	payload, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(&models.WebhookDelivery{
+		InstanceID: instanceID,
+		Payload:    payload,
+		LastError:  fmt.Sprintf("corrupt queue entry: %s", decodeErr),
+		FailedAt:   time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, s.failedKey(instanceID), data)
+		if s.failedLimit > 0 {
+			pipe.LTrim(ctx, s.failedKey(instanceID), 0, s.failedLimit-1)
+		}
+		// by value, the head may have changed since it was read
+		pipe.LRem(ctx, s.queueKey(instanceID), 1, raw)
+		return nil
+	})
+
+	return err
+}
+
+func (s *RedisWebhook) Retry(ctx context.Context, delivery *models.WebhookDelivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+
+	return s.db.LSet(ctx, s.queueKey(delivery.InstanceID), 0, data).Err()
+}
+
+func (s *RedisWebhook) Ack(ctx context.Context, instanceID string) error {
+	err := s.db.LPop(ctx, s.queueKey(instanceID)).Err()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *RedisWebhook) Fail(ctx context.Context, delivery *models.WebhookDelivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, s.failedKey(delivery.InstanceID), data)
+		if s.failedLimit > 0 {
+			pipe.LTrim(ctx, s.failedKey(delivery.InstanceID), 0, s.failedLimit-1)
+		}
+		pipe.LPop(ctx, s.queueKey(delivery.InstanceID))
+		return nil
+	})
+
+	return err
+}
+
+func (s *RedisWebhook) ListFailed(ctx context.Context, instanceID string, offset, limit int64) ([]models.WebhookDelivery, error) {
+	if instanceID == "" {
+		return nil, ErrInstanceIDEmpty
+	}
+
+	rawList, err := s.db.LRange(ctx, s.failedKey(instanceID), offset, offset+limit-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.WebhookDelivery, 0, len(rawList))
+	for _, raw := range rawList {
+		var delivery models.WebhookDelivery
+		if err := json.Unmarshal([]byte(raw), &delivery); err != nil {
+			continue
+		}
+		result = append(result, delivery)
+	}
+
+	return result, nil
+}
+
+func (s *RedisWebhook) Delete(ctx context.Context, instanceID string) error {
+	if instanceID == "" {
+		return ErrInstanceIDEmpty
+	}
+
+	return s.db.Del(ctx, s.queueKey(instanceID), s.failedKey(instanceID)).Err()
+}
+
+func (s *RedisWebhook) Pending(ctx context.Context) ([]string, error) {
+	var (
+		cursor uint64
+		ids    []string
+	)
+
+	for {
+		batch, newCursor, err := s.db.Scan(ctx, cursor, queuePrefix+"*", 100).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range batch {
+			ids = append(ids, strings.TrimPrefix(key, queuePrefix))
+		}
+		cursor = newCursor
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return ids, nil
+}
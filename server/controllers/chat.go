@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/verbeux-ai/whatsmiau/interfaces"
 	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
+	"github.com/verbeux-ai/whatsmiau/models"
 	"github.com/verbeux-ai/whatsmiau/server/dto"
 	"github.com/verbeux-ai/whatsmiau/utils"
 	"go.mau.fi/whatsmeow/types"
@@ -143,3 +145,206 @@ func (s *Chat) NumberExists(ctx echo.Context) error {
 
 	return ctx.JSON(http.StatusOK, response)
 }
+
+func (s *Chat) UpdateMessage(ctx echo.Context) error {
+	var request dto.UpdateMessageRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	if !request.Key.FromMe {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, nil, "only messages sent by the instance can be edited")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	res, err := s.whatsmiau.EditMessage(ctx.Request().Context(), &whatsmiau.EditMessageRequest{
+		InstanceID: request.InstanceID,
+		RemoteJID:  jid,
+		MessageID:  request.Key.ID,
+		Text:       request.Text,
+	})
+	if err != nil {
+		zap.L().Error("Whatsmiau.EditMessage failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to edit message")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.MessageKeyResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: jid.String(),
+			FromMe:    true,
+			Id:        request.Key.ID,
+		},
+		Status:           "sent",
+		MessageType:      "editedMessage",
+		MessageTimestamp: int(res.CreatedAt.Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Chat) DeleteMessageForEveryone(ctx echo.Context) error {
+	var request dto.DeleteMessageRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.RemoteJid)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid remoteJid format")
+	}
+
+	revoke := &whatsmiau.RevokeMessageRequest{
+		InstanceID: request.InstanceID,
+		RemoteJID:  jid,
+		MessageID:  request.ID,
+		FromMe:     request.FromMe,
+	}
+
+	if !request.FromMe {
+		if request.Participant == "" {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, nil, "participant is required to delete messages of others")
+		}
+
+		participant, err := numberToJid(request.Participant)
+		if err != nil {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid participant format")
+		}
+		revoke.Participant = participant
+	}
+
+	res, err := s.whatsmiau.RevokeMessage(ctx.Request().Context(), revoke)
+	if err != nil {
+		zap.L().Error("Whatsmiau.RevokeMessage failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to delete message")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.MessageKeyResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: jid.String(),
+			FromMe:    true,
+			Id:        res.ID,
+		},
+		Status:           "sent",
+		MessageType:      "protocolMessage",
+		MessageTimestamp: int(res.CreatedAt.Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Chat) FindMessages(ctx echo.Context) error {
+	var request dto.FindMessagesRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	if request.Page == 0 {
+		request.Page = 1
+	}
+	if request.Offset == 0 {
+		request.Offset = 50
+	}
+
+	filter := &models.MessageFilter{
+		InstanceID: request.InstanceID,
+		ID:         request.Where.Key.Id,
+		FromMe:     request.Where.Key.FromMe,
+		Offset:     (request.Page - 1) * request.Offset,
+		Limit:      request.Offset,
+	}
+
+	if request.Where.Key.RemoteJid != "" {
+		jid, err := numberToJid(request.Where.Key.RemoteJid)
+		if err != nil {
+			zap.L().Error("error converting number to jid", zap.Error(err))
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid remoteJid format")
+		}
+		filter.RemoteJid = jid.String()
+	}
+
+	if request.Where.MessageTimestamp.Gte > 0 {
+		filter.After = time.Unix(request.Where.MessageTimestamp.Gte, 0)
+	}
+	if request.Where.MessageTimestamp.Lte > 0 {
+		filter.Before = time.Unix(request.Where.MessageTimestamp.Lte, 0)
+	}
+
+	result, err := s.whatsmiau.FindMessages(ctx.Request().Context(), filter)
+	if err != nil {
+		zap.L().Error("Whatsmiau.FindMessages failed", zap.Error(err))
+		if errors.Is(err, whatsmiau.ErrMessageStoreDisabled) {
+			return utils.HTTPFail(ctx, http.StatusNotImplemented, err, "message store is disabled")
+		}
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to find messages")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.FindMessagesResponse{
+		Messages: dto.FindMessagesPage{
+			Total:       result.Total,
+			Pages:       (result.Total + request.Offset - 1) / request.Offset,
+			CurrentPage: request.Page,
+			Records:     result.Records,
+		},
+	})
+}
+
+func (s *Chat) FindChats(ctx echo.Context) error {
+	var request dto.FindChatsRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	if request.Page == 0 {
+		request.Page = 1
+	}
+	if request.Offset == 0 {
+		request.Offset = 100
+	}
+
+	filter := &models.ChatFilter{
+		InstanceID: request.InstanceID,
+		Archived:   request.Where.Archived,
+		Offset:     (request.Page - 1) * request.Offset,
+		Limit:      request.Offset,
+	}
+
+	if request.Where.RemoteJid != "" {
+		jid, err := numberToJid(request.Where.RemoteJid)
+		if err != nil {
+			zap.L().Error("error converting number to jid", zap.Error(err))
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid remoteJid format")
+		}
+		filter.RemoteJid = jid.String()
+	}
+
+	result, err := s.whatsmiau.FindChats(ctx.Request().Context(), filter)
+	if err != nil {
+		zap.L().Error("Whatsmiau.FindChats failed", zap.Error(err))
+		if errors.Is(err, whatsmiau.ErrMessageStoreDisabled) {
+			return utils.HTTPFail(ctx, http.StatusNotImplemented, err, "message store is disabled")
+		}
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to find chats")
+	}
+
+	return ctx.JSON(http.StatusOK, result.Records)
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
+	"github.com/verbeux-ai/whatsmiau/server/dto"
+	"github.com/verbeux-ai/whatsmiau/utils"
+	"go.uber.org/zap"
+)
+
+const streamPingInterval = 15 * time.Second
+
+type Event struct {
+	repo      interfaces.InstanceRepository
+	whatsmiau *whatsmiau.Whatsmiau
+}
+
+func NewEvents(repository interfaces.InstanceRepository, whatsmiau *whatsmiau.Whatsmiau) *Event {
+	return &Event{
+		repo:      repository,
+		whatsmiau: whatsmiau,
+	}
+}
+
+func (s *Event) bindStream(ctx echo.Context) (*dto.StreamEventsRequest, error) {
+	var request dto.StreamEventsRequest
+	if err := ctx.Bind(&request); err != nil {
+		return nil, utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return nil, utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	result, err := s.repo.List(ctx.Request().Context(), request.ID)
+	if err != nil {
+		zap.L().Error("failed to list instances", zap.Error(err))
+		return nil, utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to list instances")
+	}
+
+	if len(result) == 0 {
+		return nil, utils.HTTPFail(ctx, http.StatusNotFound, err, "instance not found")
+	}
+
+	return &request, nil
+}
+
+// Stream sends the instance events as Server-Sent Events, the event id is the cursor to resume from
+func (s *Event) Stream(ctx echo.Context) error {
+	request, err := s.bindStream(ctx)
+	if request == nil {
+		return err
+	}
+
+	cursor := request.Cursor
+	if len(cursor) == 0 {
+		cursor = ctx.Request().Header.Get("Last-Event-ID")
+	}
+
+	events, unsubscribe := s.whatsmiau.Subscribe(request.ID, cursor, strings.Split(request.Events, ","))
+	defer unsubscribe()
+
+	res := ctx.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	ticker := time.NewTicker(streamPingInterval)
+	defer ticker.Stop()
+
+	c := ctx.Request().Context()
+	for {
+		select {
+		case <-c.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case event, ok := <-events:
+			if !ok {
+				// too slow, the client reconnects with Last-Event-ID
+				return nil
+			}
+
+			if _, err := fmt.Fprintf(res, "id: %s\nevent: %s\ndata: %s\n\n", event.Cursor, event.Event, event.Data); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+// WebSocket sends the instance events as text frames of whatsmiau.StreamEvent
+func (s *Event) WebSocket(ctx echo.Context) error {
+	request, err := s.bindStream(ctx)
+	if request == nil {
+		return err
+	}
+
+	conn, err := websocket.Accept(ctx.Response(), ctx.Request(), &websocket.AcceptOptions{
+		OriginPatterns: env.Env.StreamOrigins, // the request host is always allowed
+	})
+	if err != nil {
+		zap.L().Warn("failed to accept websocket", zap.Error(err))
+		return nil
+	}
+	defer conn.CloseNow()
+
+	events, unsubscribe := s.whatsmiau.Subscribe(request.ID, request.Cursor, strings.Split(request.Events, ","))
+	defer unsubscribe()
+
+	ticker := time.NewTicker(streamPingInterval)
+	defer ticker.Stop()
+
+	// the client only sends control frames, CloseRead cancels the context when it disconnects
+	c := conn.CloseRead(ctx.Request().Context())
+	for {
+		select {
+		case <-c.Done():
+			return nil
+		case <-ticker.C:
+			if err := conn.Ping(c); err != nil {
+				return nil
+			}
+		case event, ok := <-events:
+			if !ok {
+				conn.Close(websocket.StatusTryAgainLater, "subscriber too slow, resume from the last cursor")
+				return nil
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				zap.L().Error("failed to marshal stream event", zap.Error(err))
+				continue
+			}
+
+			if err := conn.Write(c, websocket.MessageText, data); err != nil {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,405 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
+	"github.com/verbeux-ai/whatsmiau/server/dto"
+	"github.com/verbeux-ai/whatsmiau/utils"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
+)
+
+type Group struct {
+	repo      interfaces.InstanceRepository
+	whatsmiau *whatsmiau.Whatsmiau
+}
+
+func NewGroups(repository interfaces.InstanceRepository, whatsmiau *whatsmiau.Whatsmiau) *Group {
+	return &Group{
+		repo:      repository,
+		whatsmiau: whatsmiau,
+	}
+}
+
+// groupFailCode maps the WhatsApp group errors to the response status
+func groupFailCode(err error) int {
+	switch {
+	case errors.Is(err, whatsmeow.ErrGroupNotFound), errors.Is(err, whatsmeow.ErrIQNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, whatsmeow.ErrNotInGroup), errors.Is(err, whatsmeow.ErrIQForbidden),
+		errors.Is(err, whatsmeow.ErrIQNotAuthorized), errors.Is(err, whatsmeow.ErrGroupInviteLinkUnauthorized):
+		return http.StatusForbidden
+	case errors.Is(err, whatsmeow.ErrInviteLinkInvalid), errors.Is(err, whatsmeow.ErrInviteLinkRevoked),
+		errors.Is(err, whatsmeow.ErrInvalidImageFormat), errors.Is(err, whatsmiau.ErrInvalidGroupSetting):
+		return http.StatusBadRequest
+	default:
+		return mediaFailCode(err)
+	}
+}
+
+func (s *Group) CreateGroup(ctx echo.Context) error {
+	var request dto.CreateGroupRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	participants := make([]types.JID, 0, len(request.Participants))
+	for _, participant := range request.Participants {
+		jid, err := numberToJid(participant)
+		if err != nil {
+			zap.L().Error("error converting number to jid", zap.Error(err))
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid participant number format")
+		}
+		participants = append(participants, *jid)
+	}
+
+	group, err := s.whatsmiau.CreateGroup(ctx.Request().Context(), &whatsmiau.CreateGroupRequest{
+		InstanceID:   request.InstanceID,
+		Subject:      request.Subject,
+		Description:  request.Description,
+		Participants: participants,
+	})
+	if err != nil {
+		zap.L().Error("Whatsmiau.CreateGroup failed", zap.Error(err))
+		return utils.HTTPFail(ctx, groupFailCode(err), err, "failed to create group")
+	}
+
+	return ctx.JSON(http.StatusCreated, group)
+}
+
+func (s *Group) FetchAllGroups(ctx echo.Context) error {
+	var request dto.FetchAllGroupsRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request")
+	}
+
+	groups, err := s.whatsmiau.FetchAllGroups(ctx.Request().Context(), request.InstanceID, request.GetParticipants)
+	if err != nil {
+		zap.L().Error("Whatsmiau.FetchAllGroups failed", zap.Error(err))
+		return utils.HTTPFail(ctx, groupFailCode(err), err, "failed to fetch groups")
+	}
+
+	return ctx.JSON(http.StatusOK, groups)
+}
+
+func (s *Group) FindGroupInfos(ctx echo.Context) error {
+	var request dto.GroupRequest
+	if err := bindWithQuery(ctx, &request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request")
+	}
+
+	jid, err := groupToJid(request.GroupJid)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid groupJid format")
+	}
+
+	group, err := s.whatsmiau.GroupInfo(ctx.Request().Context(), request.InstanceID, *jid)
+	if err != nil {
+		zap.L().Error("Whatsmiau.GroupInfo failed", zap.Error(err))
+		return utils.HTTPFail(ctx, groupFailCode(err), err, "failed to get group")
+	}
+
+	return ctx.JSON(http.StatusOK, group)
+}
+
+func (s *Group) Participants(ctx echo.Context) error {
+	var request dto.GroupRequest
+	if err := bindWithQuery(ctx, &request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request")
+	}
+
+	jid, err := groupToJid(request.GroupJid)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid groupJid format")
+	}
+
+	participants, err := s.whatsmiau.GroupParticipants(ctx.Request().Context(), request.InstanceID, *jid)
+	if err != nil {
+		zap.L().Error("Whatsmiau.GroupParticipants failed", zap.Error(err))
+		return utils.HTTPFail(ctx, groupFailCode(err), err, "failed to get group participants")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.GroupParticipantsResponse{
+		Participants: participants,
+	})
+}
+
+func (s *Group) UpdateGroupSubject(ctx echo.Context) error {
+	var request dto.UpdateGroupSubjectRequest
+	if err := bindWithQuery(ctx, &request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := groupToJid(request.GroupJid)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid groupJid format")
+	}
+
+	if err := s.whatsmiau.UpdateGroupSubject(ctx.Request().Context(), request.InstanceID, *jid, request.Subject); err != nil {
+		zap.L().Error("Whatsmiau.UpdateGroupSubject failed", zap.Error(err))
+		return utils.HTTPFail(ctx, groupFailCode(err), err, "failed to update group subject")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.UpdateGroupResponse{Update: "success"})
+}
+
+func (s *Group) UpdateGroupDescription(ctx echo.Context) error {
+	var request dto.UpdateGroupDescriptionRequest
+	if err := bindWithQuery(ctx, &request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := groupToJid(request.GroupJid)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid groupJid format")
+	}
+
+	if err := s.whatsmiau.UpdateGroupDescription(ctx.Request().Context(), request.InstanceID, *jid, request.Description); err != nil {
+		zap.L().Error("Whatsmiau.UpdateGroupDescription failed", zap.Error(err))
+		return utils.HTTPFail(ctx, groupFailCode(err), err, "failed to update group description")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.UpdateGroupResponse{Update: "success"})
+}
+
+func (s *Group) UpdateGroupPicture(ctx echo.Context) error {
+	var request dto.UpdateGroupPictureRequest
+	if err := bindWithQuery(ctx, &request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := groupToJid(request.GroupJid)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid groupJid format")
+	}
+
+	if _, err := s.whatsmiau.UpdateGroupPicture(ctx.Request().Context(), &whatsmiau.UpdateGroupPictureRequest{
+		InstanceID: request.InstanceID,
+		GroupJID:   jid,
+		Image:      request.Image,
+	}); err != nil {
+		zap.L().Error("Whatsmiau.UpdateGroupPicture failed", zap.Error(err))
+		return utils.HTTPFail(ctx, groupFailCode(err), err, "failed to update group picture")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.UpdateGroupResponse{Update: "success"})
+}
+
+func (s *Group) UpdateParticipant(ctx echo.Context) error {
+	var request dto.UpdateGroupParticipantRequest
+	if err := bindWithQuery(ctx, &request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := groupToJid(request.GroupJid)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid groupJid format")
+	}
+
+	participants := make([]types.JID, 0, len(request.Participants))
+	for _, participant := range request.Participants {
+		participantJid, err := numberToJid(participant)
+		if err != nil {
+			zap.L().Error("error converting number to jid", zap.Error(err))
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid participant number format")
+		}
+		participants = append(participants, *participantJid)
+	}
+
+	results, err := s.whatsmiau.UpdateGroupParticipants(ctx.Request().Context(), &whatsmiau.UpdateGroupParticipantsRequest{
+		InstanceID:   request.InstanceID,
+		GroupJID:     jid,
+		Action:       whatsmeow.ParticipantChange(request.Action),
+		Participants: participants,
+	})
+	if err != nil {
+		zap.L().Error("Whatsmiau.UpdateGroupParticipants failed", zap.Error(err))
+		return utils.HTTPFail(ctx, groupFailCode(err), err, "failed to update group participants")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.UpdateGroupParticipantResponse{
+		UpdateParticipants: results,
+	})
+}
+
+func (s *Group) UpdateSetting(ctx echo.Context) error {
+	var request dto.UpdateGroupSettingRequest
+	if err := bindWithQuery(ctx, &request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := groupToJid(request.GroupJid)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid groupJid format")
+	}
+
+	if err := s.whatsmiau.UpdateGroupSetting(ctx.Request().Context(), request.InstanceID, *jid, request.Action); err != nil {
+		zap.L().Error("Whatsmiau.UpdateGroupSetting failed", zap.Error(err))
+		return utils.HTTPFail(ctx, groupFailCode(err), err, "failed to update group setting")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.UpdateGroupSettingResponse{UpdateSetting: true})
+}
+
+func (s *Group) InviteCode(ctx echo.Context) error {
+	var request dto.GroupRequest
+	if err := bindWithQuery(ctx, &request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request")
+	}
+
+	jid, err := groupToJid(request.GroupJid)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid groupJid format")
+	}
+
+	code, err := s.whatsmiau.GroupInviteCode(ctx.Request().Context(), request.InstanceID, *jid, false)
+	if err != nil {
+		zap.L().Error("Whatsmiau.GroupInviteCode failed", zap.Error(err))
+		return utils.HTTPFail(ctx, groupFailCode(err), err, "failed to get group invite code")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.GroupInviteCodeResponse{
+		InviteUrl:  whatsmeow.InviteLinkPrefix + code,
+		InviteCode: code,
+	})
+}
+
+func (s *Group) RevokeInviteCode(ctx echo.Context) error {
+	var request dto.GroupRequest
+	if err := bindWithQuery(ctx, &request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request")
+	}
+
+	jid, err := groupToJid(request.GroupJid)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid groupJid format")
+	}
+
+	code, err := s.whatsmiau.GroupInviteCode(ctx.Request().Context(), request.InstanceID, *jid, true)
+	if err != nil {
+		zap.L().Error("Whatsmiau.GroupInviteCode failed", zap.Error(err))
+		return utils.HTTPFail(ctx, groupFailCode(err), err, "failed to revoke group invite code")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.RevokeGroupInviteCodeResponse{
+		Revoked:    true,
+		InviteCode: code,
+	})
+}
+
+func (s *Group) InviteInfo(ctx echo.Context) error {
+	var request dto.GroupInviteRequest
+	if err := bindWithQuery(ctx, &request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request")
+	}
+
+	group, err := s.whatsmiau.GroupInviteInfo(ctx.Request().Context(), request.InstanceID, request.InviteCode)
+	if err != nil {
+		zap.L().Error("Whatsmiau.GroupInviteInfo failed", zap.Error(err))
+		return utils.HTTPFail(ctx, groupFailCode(err), err, "failed to get group invite info")
+	}
+
+	return ctx.JSON(http.StatusOK, group)
+}
+
+func (s *Group) AcceptInviteCode(ctx echo.Context) error {
+	var request dto.GroupInviteRequest
+	if err := bindWithQuery(ctx, &request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request")
+	}
+
+	jid, err := s.whatsmiau.JoinGroup(ctx.Request().Context(), request.InstanceID, request.InviteCode)
+	if err != nil {
+		zap.L().Error("Whatsmiau.JoinGroup failed", zap.Error(err))
+		return utils.HTTPFail(ctx, groupFailCode(err), err, "failed to accept group invite")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.AcceptGroupInviteResponse{
+		Accepted: true,
+		GroupJid: jid.String(),
+	})
+}
+
+func (s *Group) LeaveGroup(ctx echo.Context) error {
+	var request dto.GroupRequest
+	if err := bindWithQuery(ctx, &request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request")
+	}
+
+	jid, err := groupToJid(request.GroupJid)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid groupJid format")
+	}
+
+	if err := s.whatsmiau.LeaveGroup(ctx.Request().Context(), request.InstanceID, *jid); err != nil {
+		zap.L().Error("Whatsmiau.LeaveGroup failed", zap.Error(err))
+		return utils.HTTPFail(ctx, groupFailCode(err), err, "failed to leave group")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.LeaveGroupResponse{
+		GroupJid: jid.String(),
+		Leave:    true,
+	})
+}
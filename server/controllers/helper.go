@@ -1,10 +1,16 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
+	"mime/multipart"
+	"net/http"
 	"net/url"
 	"strings"
 
+	"github.com/labstack/echo/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
 	"github.com/verbeux-ai/whatsmiau/models"
 	"go.mau.fi/whatsmeow/types"
 )
@@ -57,6 +63,19 @@ func parseProxyURL(proxyURL string) (*models.InstanceProxy, error) {
 	}, nil
 }
 
+// redactInstance returns the instance without the webhook secret, which is never sent back
+func redactInstance(instance *models.Instance) *models.Instance {
+	if instance.Webhook == nil || instance.Webhook.Secret == "" {
+		return instance
+	}
+
+	redacted := *instance
+	webhook := *instance.Webhook
+	webhook.Secret = ""
+	redacted.Webhook = &webhook
+	return &redacted
+}
+
 func splitHostPort(h string) (string, string, error) {
 	parts := strings.Split(h, ":")
 	if len(parts) != 2 {
@@ -64,3 +83,48 @@ func splitHostPort(h string) (string, string, error) {
 	}
 	return parts[0], parts[1], nil
 }
+
+// openFormFile opens a multipart/form-data upload respecting MEDIA_MAX_SIZE, big uploads are already on disk
+func openFormFile(file *multipart.FileHeader) (multipart.File, error) {
+	if file.Size > env.Env.MediaMaxSize {
+		return nil, whatsmiau.ErrMediaTooLarge
+	}
+
+	return file.Open()
+}
+
+// mediaFailCode maps media errors to the response status, anything else is a send failure
+func mediaFailCode(err error) int {
+	switch {
+	case errors.Is(err, whatsmiau.ErrMediaTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, whatsmiau.ErrInvalidMedia):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// groupToJid accepts the group id with or without the @g.us suffix
+func groupToJid(group string) (*types.JID, error) {
+	if !strings.Contains(group, "@") {
+		group += "@" + types.GroupServer
+	}
+
+	jid, err := types.ParseJID(group)
+	if err != nil || jid.Server != types.GroupServer {
+		return nil, fmt.Errorf("invalid group jid")
+	}
+
+	return &jid, nil
+}
+
+// bindWithQuery binds like echo.Context.Bind plus the query params, which echo skips for POST requests
+// (the Evolution API sends ?groupJid= in them)
+func bindWithQuery(ctx echo.Context, i any) error {
+	if err := ctx.Bind(i); err != nil {
+		return err
+	}
+
+	return (&echo.DefaultBinder{}).BindQueryParams(ctx, i)
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/base64"
+	"errors"
 	"net/http"
 
 	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
@@ -13,6 +14,7 @@ import (
 	"github.com/verbeux-ai/whatsmiau/interfaces"
 	"github.com/verbeux-ai/whatsmiau/server/dto"
 	"github.com/verbeux-ai/whatsmiau/utils"
+	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
 	"go.uber.org/zap"
 )
@@ -55,7 +57,9 @@ func (s *Instance) Create(ctx echo.Context) error {
 		ReadStatus:      request.ReadStatus,
 		SyncFullHistory: request.SyncFullHistory,
 
-		Webhook: request.Webhook,
+		Webhook:  request.Webhook,
+		RabbitMQ: request.RabbitMQ,
+		SQS:      request.SQS,
 
 		ChatwootAccountID:               derefInt(request.ChatwootAccountID),
 		ChatwootToken:                   derefString(request.ChatwootToken),
@@ -88,7 +92,7 @@ func (s *Instance) Create(ctx echo.Context) error {
 	}
 
 	return ctx.JSON(http.StatusCreated, dto.CreateInstanceResponse{
-		Instance: instance,
+		Instance: redactInstance(&instance),
 	})
 }
 
@@ -148,28 +152,32 @@ func (s *Instance) Update(ctx echo.Context) error {
 	// 3️⃣ Atualizações parciais (PATCH)
 	// ================================
 
+	previous := *current
+	update := &models.InstanceUpdate{}
+
 	// ---------- Webhook ----------
 	if request.Webhook != nil {
-		if current.Webhook == nil {
-			current.Webhook = &models.InstanceWebhook{}
+		update.Webhook = &models.InstanceWebhookUpdate{
+			ByEvents: request.Webhook.ByEvents,
+			Base64:   request.Webhook.Base64,
+			Headers:  request.Webhook.Headers,
+			Events:   request.Webhook.Events,
+			Secret:   request.Webhook.Secret,
 		}
 		if request.Webhook.Url != "" {
-			current.Webhook.Url = request.Webhook.Url
-		}
-		if request.Webhook.Base64 != nil {
-			current.Webhook.Base64 = request.Webhook.Base64
-		}
-		if request.Webhook.Events != nil {
-			current.Webhook.Events = request.Webhook.Events
-		}
-		if request.Webhook.Headers != nil {
-			current.Webhook.Headers = request.Webhook.Headers
-		}
-		if request.Webhook.ByEvents != nil {
-			current.Webhook.ByEvents = request.Webhook.ByEvents
+			update.Webhook.Url = &request.Webhook.Url
 		}
 	}
 
+	// ---------- Settings ----------
+	update.RejectCall = request.RejectCall
+	update.MsgCall = request.MsgCall
+	update.GroupsIgnore = request.GroupsIgnore
+	update.AlwaysOnline = request.AlwaysOnline
+	update.ReadMessages = request.ReadMessages
+	update.ReadStatus = request.ReadStatus
+	update.SyncFullHistory = request.SyncFullHistory
+
 	// ---------- Chatwoot ----------
 	if request.ChatwootAccountID != nil {
 		current.ChatwootAccountID = *request.ChatwootAccountID
@@ -211,6 +219,22 @@ func (s *Instance) Update(ctx echo.Context) error {
 		current.ChatwootLogo = *request.ChatwootLogo
 	}
 
+	// ---------- RabbitMQ ----------
+	if request.RabbitMQ != nil {
+		update.RabbitMQ = &models.InstanceBroker{
+			Enabled: request.RabbitMQ.Enabled,
+			Events:  request.RabbitMQ.Events,
+		}
+	}
+
+	// ---------- SQS ----------
+	if request.SQS != nil {
+		update.SQS = &models.InstanceBroker{
+			Enabled: request.SQS.Enabled,
+			Events:  request.SQS.Events,
+		}
+	}
+
 	// ---------- Proxy ----------
 	if request.ProxyHost != nil {
 		current.ProxyHost = *request.ProxyHost
@@ -244,7 +268,7 @@ func (s *Instance) Update(ctx echo.Context) error {
 		}()),
 	)
 
-	_, err = s.repo.Update(c, request.ID, current)
+	_, err = s.repo.Update(c, request.ID, update)
 	if err != nil {
 		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to update instance")
 	}
@@ -262,8 +286,11 @@ func (s *Instance) Update(ctx echo.Context) error {
 		return utils.HTTPFail(ctx, http.StatusNotFound, nil, "instance not found after update")
 	}
 
+	// aplica as settings na conexão atual (presence, cache do handler)
+	s.whatsmiau.ReloadInstance(&previous, &updatedList[0])
+
 	return ctx.JSON(http.StatusOK, dto.UpdateInstanceResponse{
-		Instance: &updatedList[0],
+		Instance: redactInstance(&updatedList[0]),
 	})
 }
 
@@ -291,7 +318,7 @@ func (s *Instance) List(ctx echo.Context) error {
 		}
 
 		response = append(response, dto.ListInstancesResponse{
-			Instance:     &instance,
+			Instance:     redactInstance(&instance),
 			OwnerJID:     jid.ToNonAD().String(),
 			InstanceName: instance.ID,
 		})
@@ -307,7 +334,7 @@ func (s *Instance) List(ctx echo.Context) error {
 func (s *Instance) Connect(ctx echo.Context) error {
 	c := ctx.Request().Context()
 	var request dto.ConnectInstanceRequest
-	if err := ctx.Bind(&request); err != nil {
+	if err := bindWithQuery(ctx, &request); err != nil {
 		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
 	}
 
@@ -321,9 +348,22 @@ func (s *Instance) Connect(ctx echo.Context) error {
 		return utils.HTTPFail(ctx, http.StatusNotFound, err, "instance not found")
 	}
 
-	qrCode, err := s.whatsmiau.Connect(c, request.ID)
+	number := request.Number
+	if number == "" {
+		number = result[0].Number
+	}
+
+	var qrCode, pairingCode string
+	if number != "" {
+		qrCode, pairingCode, err = s.whatsmiau.ConnectWithPhone(c, request.ID, number)
+	} else {
+		qrCode, err = s.whatsmiau.Connect(c, request.ID)
+	}
 	if err != nil {
 		zap.L().Error("failed to connect instance", zap.Error(err))
+		if errors.Is(err, whatsmeow.ErrPhoneNumberTooShort) || errors.Is(err, whatsmeow.ErrPhoneNumberIsNotInternational) {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number to pair, use the country prefix")
+		}
 		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to connect instance")
 	}
 	if qrCode != "" {
@@ -333,9 +373,10 @@ func (s *Instance) Connect(ctx echo.Context) error {
 			return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to encode qrcode")
 		}
 		return ctx.JSON(http.StatusOK, dto.ConnectInstanceResponse{
-			Message:   "If instance restart this instance could be lost if you cannot connect",
-			Connected: false,
-			Base64:    "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+			Message:     "If instance restart this instance could be lost if you cannot connect",
+			Connected:   false,
+			Base64:      "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+			PairingCode: pairingCode,
 		})
 	}
 
@@ -409,6 +450,7 @@ func (s *Instance) Status(ctx echo.Context) error {
 			InstanceName: request.ID,
 			State:        string(status),
 		},
+		Connection: s.whatsmiau.ConnectionState(request.ID),
 	})
 }
 
@@ -468,11 +510,53 @@ func (s *Instance) Delete(ctx echo.Context) error {
 		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to delete instance")
 	}
 
+	if err := s.whatsmiau.DeleteInstance(c, request.ID); err != nil {
+		zap.L().Error("failed to release deleted instance", zap.String("id", request.ID), zap.Error(err))
+	}
+
 	return ctx.JSON(http.StatusOK, dto.DeleteInstanceResponse{
 		Message: "instance deleted",
 	})
 }
 
+func (s *Instance) FailedWebhooks(ctx echo.Context) error {
+	c := ctx.Request().Context()
+	var request dto.ListFailedWebhooksRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	if request.Limit == 0 {
+		request.Limit = 100
+	}
+
+	result, err := s.repo.List(c, request.ID)
+	if err != nil {
+		zap.L().Error("failed to list instances", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to list instances")
+	}
+
+	if len(result) == 0 {
+		return utils.HTTPFail(ctx, http.StatusNotFound, err, "instance not found")
+	}
+
+	failed, err := s.whatsmiau.ListFailedWebhooks(c, request.ID, request.Offset, request.Limit)
+	if err != nil {
+		zap.L().Error("failed to list failed webhooks", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to list failed webhooks")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.ListFailedWebhooksResponse{
+		Failed: failed,
+		Offset: request.Offset,
+		Limit:  request.Limit,
+	})
+}
+
 // ================================
 // Helpers para deref de ponteiros
 // ================================
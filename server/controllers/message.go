@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"regexp"
 	"time"
@@ -44,9 +46,19 @@ func (s *Message) SendText(ctx echo.Context) error {
 	}
 
 	sendText := &whatsmiau.SendText{
-		Text:       request.Text,
-		InstanceID: request.InstanceID,
-		RemoteJID:  jid,
+		Text:             request.Text,
+		InstanceID:       request.InstanceID,
+		RemoteJID:        jid,
+		MentionsEveryOne: request.MentionsEveryOne,
+		LinkPreview:      request.LinkPreview,
+	}
+
+	for _, number := range request.Mentioned {
+		mentioned, err := numberToJid(number)
+		if err != nil {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid mentioned number format")
+		}
+		sendText.Mentioned = append(sendText.Mentioned, *mentioned)
 	}
 
 	if request.Quoted != nil && len(request.Quoted.Key.Id) > 0 && len(request.Quoted.Message.Conversation) > 0 {
@@ -103,8 +115,19 @@ func (s *Message) SendAudio(ctx echo.Context) error {
 		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
 	}
 
+	var mediaFile io.Reader
+	if request.File != nil {
+		file, err := openFormFile(request.File)
+		if err != nil {
+			return utils.HTTPFail(ctx, mediaFailCode(err), err, "failed to read file")
+		}
+		defer file.Close()
+		mediaFile = file
+	}
+
 	sendText := &whatsmiau.SendAudioRequest{
 		AudioURL:   request.Audio,
+		AudioFile:  mediaFile,
 		InstanceID: request.InstanceID,
 		RemoteJID:  jid,
 	}
@@ -129,7 +152,7 @@ func (s *Message) SendAudio(ctx echo.Context) error {
 	res, err := s.whatsmiau.SendAudio(c, sendText)
 	if err != nil {
 		zap.L().Error("Whatsmiau.SendAudioRequest failed", zap.Error(err))
-		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send audio")
+		return utils.HTTPFail(ctx, mediaFailCode(err), err, "failed to send audio")
 	}
 
 	return ctx.JSON(http.StatusOK, dto.SendAudioResponse{
@@ -160,6 +183,17 @@ func (s *Message) SendMedia(ctx echo.Context) error {
 	case "image":
 		request.SendDocumentRequest.Mimetype = "image/png"
 		return s.sendImage(ctx, request.SendDocumentRequest)
+	case "video":
+		return s.sendVideo(ctx, request.SendDocumentRequest)
+	case "sticker":
+		return s.sendSticker(ctx, dto.SendStickerRequest{
+			InstanceID: request.InstanceID,
+			Number:     request.Number,
+			Sticker:    request.Media,
+			File:       request.File,
+			Delay:      request.Delay,
+			Quoted:     request.Quoted,
+		})
 	}
 
 	return s.sendDocument(ctx, request.SendDocumentRequest)
@@ -185,9 +219,23 @@ func (s *Message) sendDocument(ctx echo.Context, request dto.SendDocumentRequest
 		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
 	}
 
+	var mediaFile io.Reader
+	if request.File != nil {
+		file, err := openFormFile(request.File)
+		if err != nil {
+			return utils.HTTPFail(ctx, mediaFailCode(err), err, "failed to read file")
+		}
+		defer file.Close()
+		mediaFile = file
+		if request.FileName == "" {
+			request.FileName = request.File.Filename
+		}
+	}
+
 	sendData := &whatsmiau.SendDocumentRequest{
 		InstanceID: request.InstanceID,
 		MediaURL:   request.Media,
+		MediaFile:  mediaFile,
 		Caption:    request.Caption,
 		FileName:   request.FileName,
 		RemoteJID:  jid,
@@ -200,7 +248,7 @@ func (s *Message) sendDocument(ctx echo.Context, request dto.SendDocumentRequest
 	res, err := s.whatsmiau.SendDocument(c, sendData)
 	if err != nil {
 		zap.L().Error("Whatsmiau.SendDocument failed", zap.Error(err))
-		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send document")
+		return utils.HTTPFail(ctx, mediaFailCode(err), err, "failed to send document")
 	}
 
 	return ctx.JSON(http.StatusOK, dto.SendDocumentResponse{
@@ -236,9 +284,20 @@ func (s *Message) sendImage(ctx echo.Context, request dto.SendDocumentRequest) e
 		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
 	}
 
+	var mediaFile io.Reader
+	if request.File != nil {
+		file, err := openFormFile(request.File)
+		if err != nil {
+			return utils.HTTPFail(ctx, mediaFailCode(err), err, "failed to read file")
+		}
+		defer file.Close()
+		mediaFile = file
+	}
+
 	sendData := &whatsmiau.SendImageRequest{
 		InstanceID: request.InstanceID,
 		MediaURL:   request.Media,
+		MediaFile:  mediaFile,
 		Caption:    request.Caption,
 		RemoteJID:  jid,
 		Mimetype:   request.Mimetype,
@@ -250,7 +309,7 @@ func (s *Message) sendImage(ctx echo.Context, request dto.SendDocumentRequest) e
 	res, err := s.whatsmiau.SendImage(c, sendData)
 	if err != nil {
 		zap.L().Error("Whatsmiau.SendDocument failed", zap.Error(err))
-		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send document")
+		return utils.HTTPFail(ctx, mediaFailCode(err), err, "failed to send document")
 	}
 
 	return ctx.JSON(http.StatusOK, dto.SendDocumentResponse{
@@ -266,6 +325,137 @@ func (s *Message) sendImage(ctx echo.Context, request dto.SendDocumentRequest) e
 	})
 }
 
+func (s *Message) SendVideo(ctx echo.Context) error {
+	var request dto.SendDocumentRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	return s.sendVideo(ctx, request)
+}
+
+func (s *Message) sendVideo(ctx echo.Context, request dto.SendDocumentRequest) error {
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	var mediaFile io.Reader
+	if request.File != nil {
+		file, err := openFormFile(request.File)
+		if err != nil {
+			return utils.HTTPFail(ctx, mediaFailCode(err), err, "failed to read file")
+		}
+		defer file.Close()
+		mediaFile = file
+	}
+
+	sendData := &whatsmiau.SendVideoRequest{
+		InstanceID:  request.InstanceID,
+		MediaURL:    request.Media,
+		MediaFile:   mediaFile,
+		Caption:     request.Caption,
+		RemoteJID:   jid,
+		Mimetype:    request.Mimetype,
+		GifPlayback: request.GifPlayback,
+	}
+
+	if request.Quoted != nil && len(request.Quoted.Key.Id) > 0 && len(request.Quoted.Message.Conversation) > 0 {
+		sendData.QuoteMessage = request.Quoted.Message.Conversation
+		sendData.QuoteMessageID = request.Quoted.Key.Id
+	}
+
+	c := ctx.Request().Context()
+	time.Sleep(time.Millisecond * time.Duration(request.Delay)) // TODO: create a more robust solution
+
+	res, err := s.whatsmiau.SendVideo(c, sendData)
+	if err != nil {
+		zap.L().Error("Whatsmiau.SendVideo failed", zap.Error(err))
+		return utils.HTTPFail(ctx, mediaFailCode(err), err, "failed to send video")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SendDocumentResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        res.ID,
+		},
+		Status:           "sent",
+		MessageType:      "videoMessage",
+		MessageTimestamp: int(res.CreatedAt.Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) SendSticker(ctx echo.Context) error {
+	var request dto.SendStickerRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	return s.sendSticker(ctx, request)
+}
+
+func (s *Message) sendSticker(ctx echo.Context, request dto.SendStickerRequest) error {
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	var mediaFile io.Reader
+	if request.File != nil {
+		file, err := openFormFile(request.File)
+		if err != nil {
+			return utils.HTTPFail(ctx, mediaFailCode(err), err, "failed to read file")
+		}
+		defer file.Close()
+		mediaFile = file
+	}
+
+	sendData := &whatsmiau.SendStickerRequest{
+		InstanceID: request.InstanceID,
+		MediaURL:   request.Sticker,
+		MediaFile:  mediaFile,
+		RemoteJID:  jid,
+	}
+
+	if request.Quoted != nil && len(request.Quoted.Key.Id) > 0 && len(request.Quoted.Message.Conversation) > 0 {
+		sendData.QuoteMessage = request.Quoted.Message.Conversation
+		sendData.QuoteMessageID = request.Quoted.Key.Id
+	}
+
+	c := ctx.Request().Context()
+	time.Sleep(time.Millisecond * time.Duration(request.Delay)) // TODO: create a more robust solution
+
+	res, err := s.whatsmiau.SendSticker(c, sendData)
+	if err != nil {
+		zap.L().Error("Whatsmiau.SendSticker failed", zap.Error(err))
+		return utils.HTTPFail(ctx, mediaFailCode(err), err, "failed to send sticker")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SendDocumentResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        res.ID,
+		},
+		Status:           "sent",
+		MessageType:      "stickerMessage",
+		MessageTimestamp: int(res.CreatedAt.Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
 func (s *Message) SendReaction(ctx echo.Context) error {
 	var request dto.SendReactionRequest
 	if err := ctx.Bind(&request); err != nil {
@@ -314,3 +504,386 @@ func (s *Message) SendReaction(ctx echo.Context) error {
 		InstanceId:       request.InstanceID,
 	})
 }
+
+func (s *Message) SendButtons(ctx echo.Context) error {
+	var request dto.SendButtonsRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	sendData := &whatsmiau.SendButtonsRequest{
+		InstanceID:  request.InstanceID,
+		RemoteJID:   jid,
+		Title:       request.Title,
+		Description: request.Description,
+		Footer:      request.Footer,
+	}
+
+	messageType := "buttonsMessage"
+	for _, button := range request.Buttons {
+		if button.Type != "reply" {
+			messageType = "interactiveMessage"
+		}
+
+		sendData.Buttons = append(sendData.Buttons, whatsmiau.ButtonItem{
+			Type:        button.Type,
+			DisplayText: button.DisplayText,
+			ID:          button.ID,
+			CopyCode:    button.CopyCode,
+			URL:         button.URL,
+			PhoneNumber: button.PhoneNumber,
+			Currency:    button.Currency,
+			Name:        button.Name,
+			KeyType:     button.KeyType,
+			Key:         button.Key,
+			Amount:      button.Amount,
+			ItemName:    button.ItemName,
+		})
+	}
+
+	if request.Quoted != nil && len(request.Quoted.Key.Id) > 0 && len(request.Quoted.Message.Conversation) > 0 {
+		sendData.QuoteMessage = request.Quoted.Message.Conversation
+		sendData.QuoteMessageID = request.Quoted.Key.Id
+	}
+
+	c := ctx.Request().Context()
+	time.Sleep(time.Millisecond * time.Duration(request.Delay)) // TODO: create a more robust solution
+
+	res, err := s.whatsmiau.SendButtons(c, sendData)
+	if err != nil {
+		if errors.Is(err, whatsmiau.ErrInvalidButtons) {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid buttons")
+		}
+
+		zap.L().Error("Whatsmiau.SendButtons failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send buttons")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SendButtonsResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        res.ID,
+		},
+		Status:           "sent",
+		MessageType:      messageType,
+		MessageTimestamp: int(res.CreatedAt.Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) SendList(ctx echo.Context) error {
+	var request dto.SendListRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	sendData := &whatsmiau.SendListRequest{
+		InstanceID:  request.InstanceID,
+		RemoteJID:   jid,
+		Title:       request.Title,
+		Description: request.Description,
+		ButtonText:  request.ButtonText,
+		FooterText:  request.FooterText,
+	}
+
+	for _, section := range request.Sections {
+		listSection := whatsmiau.ListSection{Title: section.Title}
+		for _, row := range section.Rows {
+			listSection.Rows = append(listSection.Rows, whatsmiau.ListRow{
+				Title:       row.Title,
+				Description: row.Description,
+				RowID:       row.RowId,
+			})
+		}
+		sendData.Sections = append(sendData.Sections, listSection)
+	}
+
+	if request.Quoted != nil && len(request.Quoted.Key.Id) > 0 && len(request.Quoted.Message.Conversation) > 0 {
+		sendData.QuoteMessage = request.Quoted.Message.Conversation
+		sendData.QuoteMessageID = request.Quoted.Key.Id
+	}
+
+	c := ctx.Request().Context()
+	time.Sleep(time.Millisecond * time.Duration(request.Delay)) // TODO: create a more robust solution
+
+	res, err := s.whatsmiau.SendList(c, sendData)
+	if err != nil {
+		zap.L().Error("Whatsmiau.SendList failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send list")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SendListResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        res.ID,
+		},
+		Status:           "sent",
+		MessageType:      "listMessage",
+		MessageTimestamp: int(res.CreatedAt.Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) SendLocation(ctx echo.Context) error {
+	var request dto.SendLocationRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	sendData := &whatsmiau.SendLocationRequest{
+		InstanceID: request.InstanceID,
+		RemoteJID:  jid,
+		Latitude:   request.Latitude,
+		Longitude:  request.Longitude,
+		Name:       request.Name,
+		Address:    request.Address,
+	}
+
+	if request.Quoted != nil && len(request.Quoted.Key.Id) > 0 && len(request.Quoted.Message.Conversation) > 0 {
+		sendData.QuoteMessage = request.Quoted.Message.Conversation
+		sendData.QuoteMessageID = request.Quoted.Key.Id
+	}
+
+	c := ctx.Request().Context()
+	time.Sleep(time.Millisecond * time.Duration(request.Delay)) // TODO: create a more robust solution
+
+	res, err := s.whatsmiau.SendLocation(c, sendData)
+	if err != nil {
+		zap.L().Error("Whatsmiau.SendLocation failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send location")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SendLocationResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        res.ID,
+		},
+		Status:           "sent",
+		MessageType:      "locationMessage",
+		MessageTimestamp: int(res.CreatedAt.Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) SendContact(ctx echo.Context) error {
+	var request dto.SendContactRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	sendData := &whatsmiau.SendContactRequest{
+		InstanceID: request.InstanceID,
+		RemoteJID:  jid,
+	}
+
+	for _, contact := range request.Contact {
+		sendData.Contacts = append(sendData.Contacts, whatsmiau.ContactCard{
+			FullName: contact.FullName,
+			Phones: []whatsmiau.ContactPhone{{
+				Number: contact.PhoneNumber,
+				WaID:   contact.Wuid,
+			}},
+			Organization: contact.Organization,
+			Email:        contact.Email,
+			URL:          contact.Url,
+		})
+	}
+
+	if request.Quoted != nil && len(request.Quoted.Key.Id) > 0 && len(request.Quoted.Message.Conversation) > 0 {
+		sendData.QuoteMessage = request.Quoted.Message.Conversation
+		sendData.QuoteMessageID = request.Quoted.Key.Id
+	}
+
+	c := ctx.Request().Context()
+	time.Sleep(time.Millisecond * time.Duration(request.Delay)) // TODO: create a more robust solution
+
+	res, err := s.whatsmiau.SendContact(c, sendData)
+	if err != nil {
+		zap.L().Error("Whatsmiau.SendContact failed", zap.Error(err))
+		if errors.Is(err, whatsmiau.ErrInvalidContact) {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid contact")
+		}
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send contact")
+	}
+
+	messageType := "contactMessage"
+	if len(request.Contact) > 1 {
+		messageType = "contactsArrayMessage"
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SendContactResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        res.ID,
+		},
+		Status:           "sent",
+		MessageType:      messageType,
+		MessageTimestamp: int(res.CreatedAt.Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) SendPoll(ctx echo.Context) error {
+	var request dto.SendPollRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	sendData := &whatsmiau.SendPollRequest{
+		InstanceID:      request.InstanceID,
+		RemoteJID:       jid,
+		Name:            request.Name,
+		Options:         request.Values,
+		SelectableCount: request.SelectableCount,
+	}
+
+	if request.Quoted != nil && len(request.Quoted.Key.Id) > 0 && len(request.Quoted.Message.Conversation) > 0 {
+		sendData.QuoteMessage = request.Quoted.Message.Conversation
+		sendData.QuoteMessageID = request.Quoted.Key.Id
+	}
+
+	c := ctx.Request().Context()
+	time.Sleep(time.Millisecond * time.Duration(request.Delay)) // TODO: create a more robust solution
+
+	res, err := s.whatsmiau.SendPoll(c, sendData)
+	if err != nil {
+		zap.L().Error("Whatsmiau.SendPoll failed", zap.Error(err))
+		if errors.Is(err, whatsmiau.ErrInvalidPoll) {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid poll")
+		}
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send poll")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SendPollResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        res.ID,
+		},
+		Status:           "sent",
+		MessageType:      "pollCreationMessage",
+		MessageTimestamp: int(res.CreatedAt.Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) ForwardMessage(ctx echo.Context) error {
+	var request dto.ForwardMessageRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	sendData := &whatsmiau.ForwardMessageRequest{
+		InstanceID: request.InstanceID,
+		MessageID:  request.Key.Id,
+	}
+
+	numbers := make(map[string]string, len(request.Numbers))
+	for _, number := range request.Numbers {
+		jid, err := numberToJid(number)
+		if err != nil {
+			zap.L().Error("error converting number to jid", zap.Error(err))
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+		}
+
+		numbers[jid.String()] = number
+		sendData.RemoteJIDs = append(sendData.RemoteJIDs, *jid)
+	}
+
+	c := ctx.Request().Context()
+	time.Sleep(time.Millisecond * time.Duration(request.Delay)) // TODO: create a more robust solution
+
+	results, err := s.whatsmiau.ForwardMessage(c, sendData)
+	if err != nil {
+		zap.L().Error("Whatsmiau.ForwardMessage failed", zap.Error(err))
+		if errors.Is(err, whatsmiau.ErrMessageNotCached) {
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "message not found")
+		}
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to forward message")
+	}
+
+	response := dto.ForwardMessageResponse{
+		Messages:   make([]dto.ForwardMessageResult, 0, len(results)),
+		InstanceId: request.InstanceID,
+	}
+	for _, result := range results {
+		item := dto.ForwardMessageResult{
+			Key: dto.MessageResponseKey{
+				RemoteJid: numbers[result.RemoteJID.String()],
+				FromMe:    true,
+				Id:        result.ID,
+			},
+			Status:           "sent",
+			MessageTimestamp: int(result.CreatedAt.Unix()),
+		}
+
+		if result.Err != nil {
+			zap.L().Error("failed to forward message", zap.String("to", result.RemoteJID.String()), zap.Error(result.Err))
+			item.Status = "failed"
+			item.Error = result.Err.Error()
+			item.MessageTimestamp = 0
+		}
+
+		response.Messages = append(response.Messages, item)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
@@ -40,3 +40,72 @@ type SendChatPresenceResponse struct {
 type NumberExistsRequest struct {
 	Numbers []string `json:"numbers"     validate:"required,min=1,dive,required"`
 }
+
+type UpdateMessageRequest struct {
+	InstanceID string           `param:"instance" validate:"required"`
+	Number     string           `json:"number" validate:"required"`
+	Key        UpdateMessageKey `json:"key" validate:"required"`
+	Text       string           `json:"text" validate:"required"`
+}
+
+type UpdateMessageKey struct {
+	RemoteJid string `json:"remoteJid"`
+	FromMe    bool   `json:"fromMe"`
+	ID        string `json:"id" validate:"required"`
+}
+
+type DeleteMessageRequest struct {
+	InstanceID  string `param:"instance" validate:"required"`
+	ID          string `json:"id" validate:"required"`
+	RemoteJid   string `json:"remoteJid" validate:"required"`
+	FromMe      bool   `json:"fromMe"`
+	Participant string `json:"participant"` // sender of the message, required to delete messages of others in groups
+}
+
+type MessageKeyResponse struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status,omitempty"`
+	MessageType      string             `json:"messageType,omitempty"`
+	MessageTimestamp int                `json:"messageTimestamp,omitempty"`
+	InstanceId       string             `json:"instanceId,omitempty"`
+}
+
+type FindMessagesRequest struct {
+	InstanceID string            `param:"instance" validate:"required"`
+	Where      FindMessagesWhere `json:"where"`
+	Page       int               `json:"page,omitempty" validate:"omitempty,min=1"`
+	Offset     int               `json:"offset,omitempty" validate:"omitempty,min=1,max=500"` // page size
+}
+
+type FindMessagesWhere struct {
+	Key struct {
+		Id        string `json:"id,omitempty"`
+		RemoteJid string `json:"remoteJid,omitempty"`
+		FromMe    *bool  `json:"fromMe,omitempty"`
+	} `json:"key"`
+	MessageTimestamp struct {
+		Gte int64 `json:"gte,omitempty"` // unix seconds
+		Lte int64 `json:"lte,omitempty"`
+	} `json:"messageTimestamp"`
+}
+
+type FindMessagesResponse struct {
+	Messages FindMessagesPage `json:"messages"`
+}
+
+type FindMessagesPage struct {
+	Total       int `json:"total"`
+	Pages       int `json:"pages"`
+	CurrentPage int `json:"currentPage"`
+	Records     any `json:"records"`
+}
+
+type FindChatsRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Where      struct {
+		RemoteJid string `json:"remoteJid,omitempty"`
+		Archived  *bool  `json:"archived,omitempty"`
+	} `json:"where"`
+	Page   int `json:"page,omitempty" validate:"omitempty,min=1"`
+	Offset int `json:"offset,omitempty" validate:"omitempty,min=1,max=500"` // page size
+}
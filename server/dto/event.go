@@ -0,0 +1,7 @@
+package dto
+
+type StreamEventsRequest struct {
+	ID     string `param:"id" validate:"required"`
+	Events string `query:"events"` // comma separated, ex: MESSAGES_UPSERT,MESSAGES_UPDATE, empty streams all events
+	Cursor string `query:"cursor"` // resumes after this cursor, SSE clients can use Last-Event-ID instead
+}
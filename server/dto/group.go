@@ -0,0 +1,86 @@
+package dto
+
+type CreateGroupRequest struct {
+	InstanceID   string   `param:"instance" validate:"required"`
+	Subject      string   `json:"subject" validate:"required"`
+	Description  string   `json:"description,omitempty"`
+	Participants []string `json:"participants" validate:"required,min=1,dive,required"`
+}
+
+// GroupRequest is used by the routes that only need the group, sent as ?groupJid= like in the Evolution API
+type GroupRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	GroupJid   string `query:"groupJid" json:"groupJid" validate:"required"`
+}
+
+type FetchAllGroupsRequest struct {
+	InstanceID      string `param:"instance" validate:"required"`
+	GetParticipants bool   `query:"getParticipants"`
+}
+
+type GroupParticipantsResponse struct {
+	Participants any `json:"participants"`
+}
+
+type UpdateGroupSubjectRequest struct {
+	GroupRequest
+	Subject string `json:"subject" validate:"required"`
+}
+
+type UpdateGroupDescriptionRequest struct {
+	GroupRequest
+	Description string `json:"description"` // empty removes it
+}
+
+type UpdateGroupPictureRequest struct {
+	GroupRequest
+	Image string `json:"image" validate:"required"` // url, base64 or data URI
+}
+
+type UpdateGroupResponse struct {
+	Update string `json:"update"`
+}
+
+type UpdateGroupParticipantRequest struct {
+	GroupRequest
+	Action       string   `json:"action" validate:"required,oneof=add remove promote demote"`
+	Participants []string `json:"participants" validate:"required,min=1,dive,required"`
+}
+
+type UpdateGroupParticipantResponse struct {
+	UpdateParticipants any `json:"updateParticipants"`
+}
+
+type UpdateGroupSettingRequest struct {
+	GroupRequest
+	Action string `json:"action" validate:"required,oneof=announcement not_announcement locked unlocked"`
+}
+
+type UpdateGroupSettingResponse struct {
+	UpdateSetting bool `json:"updateSetting"`
+}
+
+type GroupInviteCodeResponse struct {
+	InviteUrl  string `json:"inviteUrl"`
+	InviteCode string `json:"inviteCode"`
+}
+
+type RevokeGroupInviteCodeResponse struct {
+	Revoked    bool   `json:"revoked"`
+	InviteCode string `json:"inviteCode"`
+}
+
+type GroupInviteRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	InviteCode string `query:"inviteCode" json:"inviteCode" validate:"required"` // code or https://chat.whatsapp.com/ link
+}
+
+type AcceptGroupInviteResponse struct {
+	Accepted bool   `json:"accepted"`
+	GroupJid string `json:"groupJid"`
+}
+
+type LeaveGroupResponse struct {
+	GroupJid string `json:"groupJid"`
+	Leave    bool   `json:"leave"`
+}
@@ -1,6 +1,9 @@
 package dto
 
-import "github.com/verbeux-ai/whatsmiau/models"
+import (
+	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
+	"github.com/verbeux-ai/whatsmiau/models"
+)
 
 type CreateInstanceRequest struct {
 	ID               string `json:"id,omitempty" validate:"required_without=InstanceName"`
@@ -24,8 +27,20 @@ type UpdateInstanceRequest struct {
 		Base64   *bool             `json:"base64,omitempty"`   // Ponteiro
 		Headers  map[string]string `json:"headers,omitempty"`
 		Events   []string          `json:"events,omitempty"`
+		Secret   *string           `json:"secret,omitempty"` // empty removes it
 	} `json:"webhook,omitempty"`
 
+	// ==============================
+	// SETTINGS - aplicadas sem reconectar
+	// ==============================
+	RejectCall      *bool   `json:"rejectCall,omitempty"`
+	MsgCall         *string `json:"msgCall,omitempty"`
+	GroupsIgnore    *bool   `json:"groupsIgnore,omitempty"`
+	AlwaysOnline    *bool   `json:"alwaysOnline,omitempty"`
+	ReadMessages    *bool   `json:"readMessages,omitempty"`
+	ReadStatus      *bool   `json:"readStatus,omitempty"`
+	SyncFullHistory *bool   `json:"syncFullHistory,omitempty"`
+
 	// ==============================
 	// CHATWOOT - ADICIONADO
 	// ==============================
@@ -77,13 +92,15 @@ type ListInstancesResponse struct {
 }
 
 type ConnectInstanceRequest struct {
-	ID string `param:"id" validate:"required"`
+	ID     string `param:"id" validate:"required"`
+	Number string `query:"number" json:"number,omitempty"` // pair with a code instead of the QR, defaults to the instance number
 }
 
 type ConnectInstanceResponse struct {
-	Message   string `json:"message,omitempty"`
-	Connected bool   `json:"connected,omitempty"`
-	Base64    string `json:"base64,omitempty"`
+	Message     string `json:"message,omitempty"`
+	Connected   bool   `json:"connected,omitempty"`
+	Base64      string `json:"base64,omitempty"`
+	PairingCode string `json:"pairingCode,omitempty"`
 	*models.Instance
 }
 
@@ -92,9 +109,10 @@ type StatusInstanceRequest struct {
 }
 
 type StatusInstanceResponse struct {
-	ID       string                                        `json:"id,omitempty"`
-	Status   string                                        `json:"state,omitempty"`
-	Instance *StatusInstanceResponseEvolutionCompatibility `json:"instance,omitempty"`
+	ID         string                                        `json:"id,omitempty"`
+	Status     string                                        `json:"state,omitempty"`
+	Instance   *StatusInstanceResponseEvolutionCompatibility `json:"instance,omitempty"`
+	Connection *whatsmiau.ConnectionState                    `json:"connection,omitempty"` // supervisor state, only for paired instances
 }
 
 type StatusInstanceResponseEvolutionCompatibility struct {
@@ -1,5 +1,7 @@
 package dto
 
+import "mime/multipart"
+
 type SendTextRequest struct {
 	InstanceID       string                `param:"instance" validate:"required"`
 	Number           string                `json:"number,omitempty" validate:"required"` // JID
@@ -57,10 +59,12 @@ type ContextInfoQuotedMessage struct {
 }
 
 type SendAudioRequest struct {
-	InstanceID       string                `param:"instance"`
-	Number           string                `json:"number,omitempty"`
-	Audio            string                `json:"audio,omitempty"`
-	Delay            int                   `json:"delay,omitempty" validate:"omitempty,min=0,max=300000"`
+	InstanceID string `param:"instance"`
+	Number     string `json:"number,omitempty" form:"number"`
+	// Audio is the URL, base64 or data URI of the file
+	Audio            string                `json:"audio,omitempty" form:"audio"`
+	File             *multipart.FileHeader `json:"-" form:"file"` // multipart/form-data upload, used instead of Audio
+	Delay            int                   `json:"delay,omitempty" form:"delay" validate:"omitempty,min=0,max=300000"`
 	Quoted           *MessageRequestQuoted `json:"quoted,omitempty"`
 	MentionsEveryOne bool                  `json:"mentionsEveryOne,omitempty"`
 	Mentioned        []string              `json:"mentioned,omitempty"`
@@ -115,10 +119,11 @@ const (
 	MediaTypeImage    MediaType = "image"
 	MediaTypeVideo    MediaType = "video"
 	MediaTypeDocument MediaType = "document"
+	MediaTypeSticker  MediaType = "sticker"
 )
 
 type SendMediaRequest struct {
-	Mediatype string `json:"mediatype,omitempty"`
+	Mediatype string `json:"mediatype,omitempty" form:"mediatype"`
 	SendDocumentRequest
 }
 
@@ -128,13 +133,15 @@ type SendMediaResponse struct {
 
 type SendDocumentRequest struct {
 	InstanceID string `param:"instance"`
-	Number     string `json:"number,omitempty"`
-	Mimetype   string `json:"mimetype,omitempty"`
-	Caption    string `json:"caption,omitempty"`
-	// Media is the URL of the file
-	Media            string                `json:"media,omitempty"`
-	FileName         string                `json:"fileName,omitempty"`
-	Delay            int                   `json:"delay,omitempty" validate:"omitempty,min=0,max=300000"`
+	Number     string `json:"number,omitempty" form:"number"`
+	Mimetype   string `json:"mimetype,omitempty" form:"mimetype"`
+	Caption    string `json:"caption,omitempty" form:"caption"`
+	// Media is the URL, base64 or data URI of the file
+	Media            string                `json:"media,omitempty" form:"media"`
+	File             *multipart.FileHeader `json:"-" form:"file"` // multipart/form-data upload, used instead of Media
+	FileName         string                `json:"fileName,omitempty" form:"fileName"`
+	GifPlayback      bool                  `json:"gifPlayback,omitempty" form:"gifPlayback"` // video only
+	Delay            int                   `json:"delay,omitempty" form:"delay" validate:"omitempty,min=0,max=300000"`
 	Quoted           *MessageRequestQuoted `json:"quoted,omitempty"`
 	MentionsEveryOne bool                  `json:"mentionsEveryOne,omitempty"`
 	Mentioned        []string              `json:"mentioned,omitempty"`
@@ -172,6 +179,16 @@ type SendDocumentResponseDataImage struct {
 	ContextInfo       any    `json:"contextInfo,omitempty"`
 }
 
+type SendStickerRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Number     string `json:"number,omitempty" form:"number" validate:"required"`
+	// Sticker is the URL, base64 or data URI of the image, converted to a 512x512 webp
+	Sticker string                `json:"sticker,omitempty" form:"sticker" validate:"required_without=File"`
+	File    *multipart.FileHeader `json:"-" form:"file"` // multipart/form-data upload, used instead of Sticker
+	Delay   int                   `json:"delay,omitempty" form:"delay" validate:"omitempty,min=0,max=300000"`
+	Quoted  *MessageRequestQuoted `json:"quoted,omitempty"`
+}
+
 type SendReactionRequest struct {
 	InstanceID string `param:"instance" validate:"required"`
 	Reaction   string `json:"reaction,omitempty" validate:"required,len=1"`
@@ -191,3 +208,152 @@ type SendReactionResponse struct {
 	Source           string             `json:"source,omitempty"`
 	Status           string             `json:"status,omitempty"`
 }
+
+type SendButtonsRequest struct {
+	InstanceID  string                `param:"instance" validate:"required"`
+	Number      string                `json:"number,omitempty" validate:"required"`
+	Title       string                `json:"title,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Footer      string                `json:"footer,omitempty"`
+	Buttons     []SendButtonsButton   `json:"buttons,omitempty" validate:"required,min=1,dive"`
+	Delay       int                   `json:"delay,omitempty" validate:"omitempty,min=0,max=300000"`
+	Quoted      *MessageRequestQuoted `json:"quoted,omitempty"`
+}
+
+type SendButtonsButton struct {
+	Type        string `json:"type,omitempty" validate:"required,oneof=reply copy url call pix"`
+	DisplayText string `json:"displayText,omitempty"`
+	ID          string `json:"id,omitempty"`                                        // reply
+	CopyCode    string `json:"copyCode,omitempty" validate:"required_if=Type copy"` // copy
+	URL         string `json:"url,omitempty" validate:"required_if=Type url"`       // url
+	PhoneNumber string `json:"phoneNumber,omitempty" validate:"required_if=Type call"`
+	// pix
+	Currency string `json:"currency,omitempty"`
+	Name     string `json:"name,omitempty" validate:"required_if=Type pix"`
+	KeyType  string `json:"keyType,omitempty" validate:"required_if=Type pix"`
+	Key      string `json:"key,omitempty" validate:"required_if=Type pix"`
+	Amount   int    `json:"amount,omitempty" validate:"min=0"` // cents
+	ItemName string `json:"itemName,omitempty"`
+}
+
+type SendButtonsResponse struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status,omitempty"`
+	MessageType      string             `json:"messageType,omitempty"`
+	MessageTimestamp int                `json:"messageTimestamp,omitempty"`
+	InstanceId       string             `json:"instanceId,omitempty"`
+}
+
+type SendListRequest struct {
+	InstanceID  string                `param:"instance" validate:"required"`
+	Number      string                `json:"number,omitempty" validate:"required"`
+	Title       string                `json:"title,omitempty" validate:"required"`
+	Description string                `json:"description,omitempty"`
+	ButtonText  string                `json:"buttonText,omitempty" validate:"required"`
+	FooterText  string                `json:"footerText,omitempty"`
+	Sections    []SendListSection     `json:"sections,omitempty" validate:"required,min=1,max=10,dive"`
+	Delay       int                   `json:"delay,omitempty" validate:"omitempty,min=0,max=300000"`
+	Quoted      *MessageRequestQuoted `json:"quoted,omitempty"`
+}
+
+type SendListSection struct {
+	Title string        `json:"title,omitempty" validate:"required"`
+	Rows  []SendListRow `json:"rows,omitempty" validate:"required,min=1,dive"`
+}
+
+type SendListRow struct {
+	Title       string `json:"title,omitempty" validate:"required"`
+	Description string `json:"description,omitempty"`
+	RowId       string `json:"rowId,omitempty"`
+}
+
+type SendListResponse struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status,omitempty"`
+	MessageType      string             `json:"messageType,omitempty"`
+	MessageTimestamp int                `json:"messageTimestamp,omitempty"`
+	InstanceId       string             `json:"instanceId,omitempty"`
+}
+
+type SendLocationRequest struct {
+	InstanceID string                `param:"instance" validate:"required"`
+	Number     string                `json:"number,omitempty" validate:"required"`
+	Name       string                `json:"name,omitempty"`
+	Address    string                `json:"address,omitempty"`
+	Latitude   float64               `json:"latitude" validate:"min=-90,max=90"`
+	Longitude  float64               `json:"longitude" validate:"min=-180,max=180"`
+	Delay      int                   `json:"delay,omitempty" validate:"omitempty,min=0,max=300000"`
+	Quoted     *MessageRequestQuoted `json:"quoted,omitempty"`
+}
+
+type SendLocationResponse struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status,omitempty"`
+	MessageType      string             `json:"messageType,omitempty"`
+	MessageTimestamp int                `json:"messageTimestamp,omitempty"`
+	InstanceId       string             `json:"instanceId,omitempty"`
+}
+
+type SendContactRequest struct {
+	InstanceID string                `param:"instance" validate:"required"`
+	Number     string                `json:"number,omitempty" validate:"required"`
+	Contact    []SendContactItem     `json:"contact,omitempty" validate:"required,min=1,max=50,dive"`
+	Delay      int                   `json:"delay,omitempty" validate:"omitempty,min=0,max=300000"`
+	Quoted     *MessageRequestQuoted `json:"quoted,omitempty"`
+}
+
+type SendContactItem struct {
+	FullName     string `json:"fullName,omitempty" validate:"required"`
+	Wuid         string `json:"wuid,omitempty"` // WhatsApp number (digits), taken from phoneNumber when empty
+	PhoneNumber  string `json:"phoneNumber,omitempty" validate:"required"`
+	Organization string `json:"organization,omitempty"`
+	Email        string `json:"email,omitempty" validate:"omitempty,email"`
+	Url          string `json:"url,omitempty" validate:"omitempty,url"`
+}
+
+type SendContactResponse struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status,omitempty"`
+	MessageType      string             `json:"messageType,omitempty"`
+	MessageTimestamp int                `json:"messageTimestamp,omitempty"`
+	InstanceId       string             `json:"instanceId,omitempty"`
+}
+
+type SendPollRequest struct {
+	InstanceID      string                `param:"instance" validate:"required"`
+	Number          string                `json:"number,omitempty" validate:"required"`
+	Name            string                `json:"name,omitempty" validate:"required"`
+	SelectableCount int                   `json:"selectableCount,omitempty" validate:"min=0"` // 0 allows any number of options
+	Values          []string              `json:"values,omitempty" validate:"required,min=2,max=12,dive,required"`
+	Delay           int                   `json:"delay,omitempty" validate:"omitempty,min=0,max=300000"`
+	Quoted          *MessageRequestQuoted `json:"quoted,omitempty"`
+}
+
+type SendPollResponse struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status,omitempty"`
+	MessageType      string             `json:"messageType,omitempty"`
+	MessageTimestamp int                `json:"messageTimestamp,omitempty"`
+	InstanceId       string             `json:"instanceId,omitempty"`
+}
+
+type ForwardMessageRequest struct {
+	InstanceID string   `param:"instance" validate:"required"`
+	Numbers    []string `json:"numbers,omitempty" validate:"required,min=1,max=50,dive,required"`
+	Key        struct {
+		Id string `json:"id,omitempty" validate:"required"`
+	} `json:"key"`
+	Delay int `json:"delay,omitempty" validate:"omitempty,min=0,max=300000"`
+}
+
+type ForwardMessageResponse struct {
+	Messages   []ForwardMessageResult `json:"messages"`
+	InstanceId string                 `json:"instanceId,omitempty"`
+}
+
+type ForwardMessageResult struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status,omitempty"` // sent or failed
+	Error            string             `json:"error,omitempty"`
+	MessageTimestamp int                `json:"messageTimestamp,omitempty"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+import "github.com/verbeux-ai/whatsmiau/models"
+
+type ListFailedWebhooksRequest struct {
+	ID     string `param:"id" validate:"required"`
+	Offset int64  `query:"offset" validate:"omitempty,min=0"`
+	Limit  int64  `query:"limit" validate:"omitempty,min=1,max=1000"`
+}
+
+type ListFailedWebhooksResponse struct {
+	Failed []models.WebhookDelivery `json:"failed"`
+	Offset int64                    `json:"offset"`
+	Limit  int64                    `json:"limit"`
+}
@@ -20,6 +20,24 @@ func Auth(ctx echo.Context, next echo.HandlerFunc) error {
 	return next(ctx)
 }
 
+// StreamAuth also accepts ?apikey= since browsers can't set headers on EventSource/WebSocket connections,
+// it is only used by the stream routes
+func StreamAuth(ctx echo.Context, next echo.HandlerFunc) error {
+	gotApikey := ctx.Request().Header.Get("apikey")
+	if len(gotApikey) == 0 {
+		gotApikey = ctx.QueryParam("apikey")
+	}
+	if len(env.Env.ApiKey) == 0 {
+		return next(ctx)
+	}
+
+	if gotApikey != env.Env.ApiKey {
+		return echo.NewHTTPError(http.StatusUnauthorized)
+	}
+
+	return next(ctx)
+}
+
 type simplifiedMiddleware func(c echo.Context, next echo.HandlerFunc) error
 
 func Simplify(handler simplifiedMiddleware) func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -14,6 +14,10 @@ func Chat(group *echo.Group) {
 
 	group.POST("/presence", controller.SendChatPresence)
 	group.POST("/read-messages", controller.ReadMessages)
+	group.POST("/update-message", controller.UpdateMessage)
+	group.DELETE("/delete-message", controller.DeleteMessageForEveryone)
+	group.POST("/find-messages", controller.FindMessages)
+	group.POST("/find-chats", controller.FindChats)
 }
 
 func ChatEVO(group *echo.Group) {
@@ -24,4 +28,8 @@ func ChatEVO(group *echo.Group) {
 	group.POST("/markMessageAsRead/:instance", controller.ReadMessages)
 	group.POST("/sendPresence/:instance", controller.SendChatPresence)
 	group.POST("/whatsappNumbers/:instance", controller.NumberExists)
+	group.POST("/updateMessage/:instance", controller.UpdateMessage)
+	group.DELETE("/deleteMessageForEveryone/:instance", controller.DeleteMessageForEveryone)
+	group.POST("/findMessages/:instance", controller.FindMessages)
+	group.POST("/findChats/:instance", controller.FindChats)
 }
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
+	"github.com/verbeux-ai/whatsmiau/repositories/instances"
+	"github.com/verbeux-ai/whatsmiau/server/controllers"
+	"github.com/verbeux-ai/whatsmiau/services"
+)
+
+func Group(group *echo.Group) {
+	redisInstance := instances.NewRedis(services.Redis())
+	controller := controllers.NewGroups(redisInstance, whatsmiau.Get())
+
+	// Evolution API Compatibility, the group is sent as ?groupJid=
+	group.POST("/create/:instance", controller.CreateGroup)
+	group.GET("/fetchAllGroups/:instance", controller.FetchAllGroups)
+	group.GET("/findGroupInfos/:instance", controller.FindGroupInfos)
+	group.GET("/participants/:instance", controller.Participants)
+	group.POST("/updateGroupSubject/:instance", controller.UpdateGroupSubject)
+	group.POST("/updateGroupDescription/:instance", controller.UpdateGroupDescription)
+	group.POST("/updateGroupPicture/:instance", controller.UpdateGroupPicture)
+	group.POST("/updateParticipant/:instance", controller.UpdateParticipant)
+	group.POST("/updateSetting/:instance", controller.UpdateSetting)
+	group.GET("/inviteCode/:instance", controller.InviteCode)
+	group.POST("/revokeInviteCode/:instance", controller.RevokeInviteCode)
+	group.GET("/inviteInfo/:instance", controller.InviteInfo)
+	group.GET("/acceptInviteCode/:instance", controller.AcceptInviteCode)
+	group.DELETE("/leaveGroup/:instance", controller.LeaveGroup)
+}
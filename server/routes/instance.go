@@ -18,6 +18,7 @@ func Instance(group *echo.Group) {
 	group.POST("/:id/logout", controller.Logout)
 	group.DELETE("/:id", controller.Delete)
 	group.GET("/:id/status", controller.Status)
+	group.GET("/:id/webhook/failed", controller.FailedWebhooks)
 
 	// Evolution API Compatibility (partially REST)
 	group.POST("/create", controller.Create)
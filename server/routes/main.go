@@ -14,6 +14,11 @@ func Load(app *echo.Echo) {
 	v1Group := app.Group("/v1")
 	v1Group.Use(middleware.Simplify(middleware.Auth))
 	V1(v1Group)
+
+	// streams also accept the api key in the query string, outside of the v1 group auth
+	streamGroup := app.Group("/v1/instance/:id/events")
+	streamGroup.Use(middleware.Simplify(middleware.StreamAuth))
+	Event(streamGroup)
 }
 
 func V1(group *echo.Group) {
@@ -23,4 +28,5 @@ func V1(group *echo.Group) {
 	Chat(group.Group("/instance/:instance/chat"))
 	ChatEVO(group.Group("/chat"))
 	MessageEVO(group.Group("/message"))
+	Group(group.Group("/group"))
 }
@@ -1,7 +1,12 @@
 package routes
 
 import (
+	"encoding/base64"
+	"strconv"
+
 	"github.com/labstack/echo/v4"
+	echoMiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/verbeux-ai/whatsmiau/env"
 	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
 	"github.com/verbeux-ai/whatsmiau/repositories/instances"
 	"github.com/verbeux-ai/whatsmiau/server/controllers"
@@ -11,20 +16,44 @@ import (
 func Message(group *echo.Group) {
 	redisInstance := instances.NewRedis(services.Redis())
 	controller := controllers.NewMessages(redisInstance, whatsmiau.Get())
+	group.Use(sendBodyLimit())
 
 	group.POST("/text", controller.SendText)
 	group.POST("/audio", controller.SendAudio)
 	group.POST("/document", controller.SendDocument)
 	group.POST("/image", controller.SendImage)
+	group.POST("/video", controller.SendVideo)
+	group.POST("/sticker", controller.SendSticker)
+	group.POST("/buttons", controller.SendButtons)
+	group.POST("/list", controller.SendList)
+	group.POST("/location", controller.SendLocation)
+	group.POST("/contact", controller.SendContact)
+	group.POST("/poll", controller.SendPoll)
+	group.POST("/forward", controller.ForwardMessage)
 }
 
 func MessageEVO(group *echo.Group) {
 	redisInstance := instances.NewRedis(services.Redis())
 	controller := controllers.NewMessages(redisInstance, whatsmiau.Get())
+	group.Use(sendBodyLimit())
 
 	// Evolution API Compatibility (partially REST)
 	group.POST("/sendText/:instance", controller.SendText)
 	group.POST("/sendWhatsAppAudio/:instance", controller.SendAudio) // is always whatsapp 🤣
 	group.POST("/sendMedia/:instance", controller.SendMedia)
+	group.POST("/sendSticker/:instance", controller.SendSticker)
 	group.POST("/sendReaction/:instance", controller.SendReaction)
+	group.POST("/sendButtons/:instance", controller.SendButtons)
+	group.POST("/sendList/:instance", controller.SendList)
+	group.POST("/sendLocation/:instance", controller.SendLocation)
+	group.POST("/sendContact/:instance", controller.SendContact)
+	group.POST("/sendPoll/:instance", controller.SendPoll)
+	group.POST("/forwardMessage/:instance", controller.ForwardMessage)
+}
+
+// sendBodyLimit refuses with 413 bodies bigger than MEDIA_MAX_SIZE encoded as base64 plus 1MB for the other
+// fields, before they are read in memory
+func sendBodyLimit() echo.MiddlewareFunc {
+	limit := int64(base64.StdEncoding.EncodedLen(int(env.Env.MediaMaxSize))) + 1<<20
+	return echoMiddleware.BodyLimit(strconv.FormatInt(limit, 10))
 }
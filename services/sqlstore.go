@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -12,14 +13,29 @@ import (
 )
 
 var sqlStoreInstance *sqlstore.Container
+var sqlDBInstance *sql.DB
+
+// SQLDB is the database of the whatsmeow store, also used by the message store
+func SQLDB() *sql.DB {
+	if sqlDBInstance == nil {
+		db, err := sql.Open(env.Env.DBDialect, env.Env.DBURL)
+		if err != nil {
+			zap.L().Panic("failed to open database", zap.Error(err))
+		}
+
+		sqlDBInstance = db
+	}
+
+	return sqlDBInstance
+}
 
 func SQLStore() *sqlstore.Container {
 	ctx, c := context.WithTimeout(context.Background(), 10*time.Second)
 	defer c()
 
 	if sqlStoreInstance == nil {
-		container, err := sqlstore.New(ctx, env.Env.DBDialect, env.Env.DBURL, nil)
-		if err != nil {
+		container := sqlstore.NewWithDB(SQLDB(), env.Env.DBDialect, nil)
+		if err := container.Upgrade(ctx); err != nil {
 			zap.L().Panic("failed to start sqlstore", zap.Error(err))
 		}
 